@@ -0,0 +1,197 @@
+//
+// Copyright (C) 2019 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file implements serialization of cursors into opaque tokens
+//
+
+package dynamo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/faults"
+	"github.com/fogfish/opts"
+)
+
+const (
+	errInvalidCursor    = faults.Type("invalid cursor")
+	errInvalidCursorKey = faults.Type("invalid cursor key")
+)
+
+// CursorOption type to configure the cursor codec
+type CursorOption = opts.Option[CursorOptions]
+
+// CursorOptions of the cursor codec
+type CursorOptions struct {
+	signingKey    []byte
+	encryptionKey []byte
+}
+
+var (
+	// Sign tokens with HMAC-SHA256 using the key, tampered tokens are rejected
+	WithCursorSigningKey = opts.ForName[CursorOptions, []byte]("signingKey")
+
+	// Encrypt tokens with AES-GCM using the key, the key is either 16, 24, or
+	// 32 bytes to select AES-128, AES-192, or AES-256.
+	WithCursorEncryptionKey = opts.ForName[CursorOptions, []byte]("encryptionKey")
+)
+
+// CursorCodec encodes cursors returned by Match into URL-safe opaque
+// tokens and decodes them back. Tokens are portable across storages,
+// they are suitable for exposing pagination to clients of REST API.
+//
+//	codec, err := dynamo.NewCursorCodec[Person](dynamo.WithCursorSigningKey(key))
+//
+//	seq, cursor, err := db.Match(ctx, key, dynamo.Limit[Person](25))
+//	next, err := codec.Encode(cursor)
+//
+//	cursor, err := codec.Decode(next)
+//	seq, cursor, err := db.Match(ctx, key, dynamo.Limit[Person](25), cursor)
+type CursorCodec[T Thing] struct {
+	CursorOptions
+	aead cipher.AEAD
+}
+
+// NewCursorCodec creates codec of cursors
+func NewCursorCodec[T Thing](opt ...CursorOption) (*CursorCodec[T], error) {
+	var conf CursorOptions
+	if err := opts.Apply(&conf, opt); err != nil {
+		return nil, err
+	}
+
+	codec := &CursorCodec[T]{CursorOptions: conf}
+
+	if len(conf.encryptionKey) != 0 {
+		block, err := aes.NewCipher(conf.encryptionKey)
+		if err != nil {
+			return nil, errInvalidCursorKey.New(err)
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, errInvalidCursorKey.New(err)
+		}
+		codec.aead = aead
+	}
+
+	return codec, nil
+}
+
+// wire format of the cursor
+type cursorToken struct {
+	Hash curie.IRI       `json:"h,omitempty"`
+	Sort curie.IRI       `json:"s,omitempty"`
+	Key  json.RawMessage `json:"k,omitempty"`
+}
+
+func (c cursorToken) HashKey() curie.IRI { return c.Hash }
+func (c cursorToken) SortKey() curie.IRI { return c.Sort }
+
+func (c cursorToken) CursorKey() json.RawMessage { return c.Key }
+
+// Encode cursor into opaque token. The nil cursor (end of collection) is
+// encoded as empty token.
+func (codec *CursorCodec[T]) Encode(cursor interface{ MatcherOpt(T) }) (string, error) {
+	if cursor == nil {
+		return "", nil
+	}
+
+	var thing Thing
+	switch v := cursor.(type) {
+	case interface{ Cursor() Thing }:
+		thing = v.Cursor()
+	case Thing:
+		thing = v
+	default:
+		return "", errInvalidCursor.New(nil)
+	}
+
+	token := cursorToken{Hash: thing.HashKey(), Sort: thing.SortKey()}
+
+	// Storages attach own key details (e.g. attributes of secondary index)
+	if v, ok := thing.(interface{ CursorKey() json.RawMessage }); ok {
+		token.Key = v.CursorKey()
+	}
+
+	data, err := json.Marshal(token)
+	if err != nil {
+		return "", errInvalidCursor.New(err)
+	}
+
+	if codec.aead != nil {
+		nonce := make([]byte, codec.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return "", errInvalidCursor.New(err)
+		}
+		data = codec.aead.Seal(nonce, nonce, data, nil)
+	}
+
+	if len(codec.signingKey) != 0 {
+		data = append(data, codec.sign(data)...)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// Decode token into cursor. The empty token is decoded as nil cursor, it
+// is safe to pass it to Match.
+func (codec *CursorCodec[T]) Decode(token string) (interface{ MatcherOpt(T) }, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errInvalidCursor.New(err)
+	}
+
+	if len(codec.signingKey) != 0 {
+		if len(data) < sha256.Size {
+			return nil, errInvalidCursor.New(nil)
+		}
+
+		sign := data[len(data)-sha256.Size:]
+		data = data[:len(data)-sha256.Size]
+		if !hmac.Equal(sign, codec.sign(data)) {
+			return nil, errInvalidCursor.New(nil)
+		}
+	}
+
+	if codec.aead != nil {
+		size := codec.aead.NonceSize()
+		if len(data) < size {
+			return nil, errInvalidCursor.New(nil)
+		}
+
+		data, err = codec.aead.Open(nil, data[:size], data[size:], nil)
+		if err != nil {
+			return nil, errInvalidCursor.New(err)
+		}
+	}
+
+	var c cursorToken
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errInvalidCursor.New(err)
+	}
+
+	return Cursor[T](c), nil
+}
+
+func (codec *CursorCodec[T]) sign(data []byte) []byte {
+	mac := hmac.New(sha256.New, codec.signingKey)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,87 @@
+//
+// Copyright (C) 2019 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package dynamo_test
+
+import (
+	"testing"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/it/v2"
+)
+
+type tCursor struct {
+	Prefix curie.IRI
+	Suffix curie.IRI
+}
+
+func (x tCursor) HashKey() curie.IRI { return x.Prefix }
+func (x tCursor) SortKey() curie.IRI { return x.Suffix }
+
+func TestCursorCodec(t *testing.T) {
+	cursor := dynamo.Cursor[tCursor](tCursor{Prefix: "a:b", Suffix: "c/d"})
+
+	for name, opts := range map[string][]dynamo.CursorOption{
+		"Plain":     {},
+		"Signed":    {dynamo.WithCursorSigningKey([]byte("secret"))},
+		"Encrypted": {dynamo.WithCursorEncryptionKey([]byte("0123456789abcdef"))},
+		"SignedEncrypted": {
+			dynamo.WithCursorSigningKey([]byte("secret")),
+			dynamo.WithCursorEncryptionKey([]byte("0123456789abcdef")),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := dynamo.NewCursorCodec[tCursor](opts...)
+			it.Then(t).Should(it.Nil(err))
+
+			token, err := codec.Encode(cursor)
+			it.Then(t).Should(it.Nil(err))
+
+			val, err := codec.Decode(token)
+			it.Then(t).Should(
+				it.Nil(err),
+				it.Equal(val.(dynamo.Thing).HashKey(), "a:b"),
+				it.Equal(val.(dynamo.Thing).SortKey(), "c/d"),
+			)
+		})
+	}
+
+	t.Run("Nil", func(t *testing.T) {
+		codec, err := dynamo.NewCursorCodec[tCursor]()
+		it.Then(t).Should(it.Nil(err))
+
+		token, err := codec.Encode(nil)
+		it.Then(t).Should(it.Nil(err), it.Equal(token, ""))
+
+		val, err := codec.Decode("")
+		it.Then(t).Should(it.Nil(err), it.True(val == nil))
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		codec, err := dynamo.NewCursorCodec[tCursor](dynamo.WithCursorSigningKey([]byte("secret")))
+		it.Then(t).Should(it.Nil(err))
+
+		other, err := dynamo.NewCursorCodec[tCursor](dynamo.WithCursorSigningKey([]byte("other")))
+		it.Then(t).Should(it.Nil(err))
+
+		token, err := other.Encode(cursor)
+		it.Then(t).Should(it.Nil(err))
+
+		_, err = codec.Decode(token)
+		it.Then(t).ShouldNot(it.Nil(err))
+
+		_, err = codec.Decode("!invalid!")
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+
+	t.Run("InvalidEncryptionKey", func(t *testing.T) {
+		_, err := dynamo.NewCursorCodec[tCursor](dynamo.WithCursorEncryptionKey([]byte("short")))
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+}
@@ -0,0 +1,117 @@
+//
+// Copyright (C) 2019 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file declares errors emitted by storages
+//
+
+package dynamo
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors, use errors.Is to classify errors of storages
+//
+//	if errors.Is(err, dynamo.ErrNotFound) { ... }
+var (
+	ErrNotFound           = errors.New("not found")
+	ErrPreConditionFailed = errors.New("pre condition failed")
+	ErrConflict           = errors.New("conflict")
+	ErrGone               = errors.New("gone")
+	ErrThrottled          = errors.New("throttled")
+	ErrValidation         = errors.New("validation failed")
+	ErrItemTooLarge       = errors.New("item too large")
+	ErrPartialBatch       = errors.New("batch i/o failed partially")
+)
+
+// NotFoundError is returned when the item does not exist in the storage.
+// It matches ErrNotFound.
+type NotFoundError struct {
+	Thing       // key of the item
+	Err   error // error of the storage, if any
+}
+
+func (e *NotFoundError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("Not Found (%s, %s)", e.HashKey(), e.SortKey())
+	}
+	return fmt.Sprintf("Not Found (%s, %s): %v", e.HashKey(), e.SortKey(), e.Err)
+}
+
+func (e *NotFoundError) Unwrap() error { return e.Err }
+
+func (e *NotFoundError) Is(target error) bool { return target == ErrNotFound }
+
+func (e *NotFoundError) NotFound() string { return e.HashKey().Safe() + " " + e.SortKey().Safe() }
+
+// PreConditionFailedError is returned when the condition of write operation
+// fails. It matches ErrPreConditionFailed and the Failure, which is either
+// ErrConflict (the item exists but does not satisfy the condition), ErrGone
+// (the item is missing), both of them or nil if the failure is unknown.
+type PreConditionFailedError struct {
+	Thing          // key of the item
+	Failure error  // ErrConflict, ErrGone or their join
+	Failed  string // failed clause, if known
+	Current Thing  // current item, if known
+	Err     error  // error of the storage, if any
+}
+
+func (e *PreConditionFailedError) Error() string {
+	return fmt.Sprintf("Pre Condition Failed (%s, %s)", e.HashKey(), e.SortKey())
+}
+
+func (e *PreConditionFailedError) Unwrap() error { return e.Err }
+
+func (e *PreConditionFailedError) Is(target error) bool {
+	return target == ErrPreConditionFailed || (e.Failure != nil && errors.Is(e.Failure, target))
+}
+
+func (e *PreConditionFailedError) PreConditionFailed() bool { return true }
+
+func (e *PreConditionFailedError) Conflict() bool { return errors.Is(e.Failure, ErrConflict) }
+
+func (e *PreConditionFailedError) Gone() bool { return errors.Is(e.Failure, ErrGone) }
+
+func (e *PreConditionFailedError) Clause() string { return e.Failed }
+
+// Failure builds the Failure of PreConditionFailedError
+func Failure(conflict, gone bool) error {
+	switch {
+	case conflict && gone:
+		return errors.Join(ErrConflict, ErrGone)
+	case conflict:
+		return ErrConflict
+	case gone:
+		return ErrGone
+	}
+	return nil
+}
+
+// ServiceError is the failure of storage service classified by the Kind,
+// which is one of ErrThrottled, ErrValidation, ErrItemTooLarge or
+// ErrPartialBatch. It matches the Kind and the original error of service.
+type ServiceError struct {
+	Kind error
+	Err  error
+}
+
+func (e *ServiceError) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *ServiceError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
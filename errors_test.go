@@ -0,0 +1,76 @@
+//
+// Copyright (C) 2019 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package dynamo_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/faults"
+	"github.com/fogfish/it/v2"
+)
+
+func TestErrors(t *testing.T) {
+	key := tCursor{Prefix: "a", Suffix: "b"}
+
+	t.Run("NotFound", func(t *testing.T) {
+		err := fmt.Errorf("wrap: %w", &dynamo.NotFoundError{Thing: key})
+
+		var e *dynamo.NotFoundError
+		it.Then(t).Should(
+			it.True(errors.Is(err, dynamo.ErrNotFound)),
+			it.True(errors.As(err, &e)),
+			it.True(faults.IsNotFound(err)),
+			it.Equal(e.Error(), "Not Found (a, b)"),
+		)
+	})
+
+	t.Run("PreConditionFailed", func(t *testing.T) {
+		err := fmt.Errorf("wrap: %w", &dynamo.PreConditionFailedError{
+			Thing:   key,
+			Failure: dynamo.Failure(true, false),
+			Failed:  "name = x",
+		})
+
+		var e *dynamo.PreConditionFailedError
+		it.Then(t).Should(
+			it.True(errors.Is(err, dynamo.ErrPreConditionFailed)),
+			it.True(errors.Is(err, dynamo.ErrConflict)),
+			it.True(!errors.Is(err, dynamo.ErrGone)),
+			it.True(errors.As(err, &e)),
+			it.True(faults.IsPreConditionFailed(err)),
+			it.True(faults.IsConflict(err)),
+			it.Equal(e.Clause(), "name = x"),
+		)
+	})
+
+	t.Run("ConflictAndGone", func(t *testing.T) {
+		err := &dynamo.PreConditionFailedError{Thing: key, Failure: dynamo.Failure(true, true)}
+		it.Then(t).Should(
+			it.True(errors.Is(err, dynamo.ErrConflict)),
+			it.True(errors.Is(err, dynamo.ErrGone)),
+		)
+	})
+
+	t.Run("Service", func(t *testing.T) {
+		cause := errors.New("slow down")
+		err := fmt.Errorf("wrap: %w", &dynamo.ServiceError{Kind: dynamo.ErrThrottled, Err: cause})
+
+		var e *dynamo.ServiceError
+		it.Then(t).Should(
+			it.True(errors.Is(err, dynamo.ErrThrottled)),
+			it.True(errors.Is(err, cause)),
+			it.True(!errors.Is(err, dynamo.ErrValidation)),
+			it.True(errors.As(err, &e)),
+			it.Equal(e.Error(), "throttled: slow down"),
+		)
+	})
+}
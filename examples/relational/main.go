@@ -27,7 +27,7 @@ func main() {
 	dbk := ddb.Must(ddb.New[Keyword]("example-dynamo-relational"))
 
 	lsi := ddb.Must(ddb.New[Article]("example-dynamo-relational",
-		ddb.WithGlobalSecondaryIndex("example-dynamo-relational-year"),
+		ddb.WithLocalSecondaryIndex("example-dynamo-relational-year"),
 		ddb.WithSortKey("year"),
 	))
 	gsi := ddb.Must(ddb.New[Category]("example-dynamo-relational",
@@ -71,6 +71,10 @@ func main() {
 	//
 	// As a reader I want to list all articles written by the author in chronological order ...
 	assert(lookupByAuthorOrderedByTime(lsi, "neumann"))
+
+	//
+	// As a reader I want to list all articles written by the author, latest first ...
+	assert(lookupByAuthorLatestFirst(lsi, "neumann"))
 }
 
 // As a reader I want to fetch the article ...
@@ -193,16 +197,33 @@ func lookupByAuthorOrderedByTime(db dynamo.KeyVal[Article], author string) error
 	return stdio(seq)
 }
 
+func lookupByAuthorLatestFirst(db dynamo.KeyVal[Article], author string) error {
+	log.Printf("==> lookup articles in reverse chronological order: %s", author)
+
+	seq, _, err := db.Match(context.Background(),
+		Article{
+			Author: curie.New("author", author),
+		},
+		dynamo.Reverse[Article](),
+	)
+
+	if err != nil {
+		return err
+	}
+
+	return stdio(seq)
+}
+
 func articlesOfJohnVonNeumann(
-	db dynamo.KeyVal[Author],
-	dba dynamo.KeyVal[Article],
-	dbk dynamo.KeyVal[Keyword],
+	db *ddb.Storage[Author],
+	dba *ddb.Storage[Article],
+	dbk *ddb.Storage[Keyword],
 ) error {
 	if err := registerAuthor(db, "neumann", "John von Neumann"); err != nil {
 		return err
 	}
 
-	err := publishArticle(dba, dbk, "neumann",
+	err := publishArticle(db, dba, dbk, "neumann",
 		"theory_of_set",
 		"An axiomatization of set theory",
 		[]string{"theory", "math"},
@@ -211,7 +232,7 @@ func articlesOfJohnVonNeumann(
 		return err
 	}
 
-	err = publishArticle(dba, dbk, "neumann",
+	err = publishArticle(db, dba, dbk, "neumann",
 		"theory_of_automata",
 		"The general and logical theory of automata",
 		[]string{"theory", "computer"},
@@ -224,15 +245,15 @@ func articlesOfJohnVonNeumann(
 }
 
 func articlesOfLeonardKleinrock(
-	db dynamo.KeyVal[Author],
-	dba dynamo.KeyVal[Article],
-	dbk dynamo.KeyVal[Keyword],
+	db *ddb.Storage[Author],
+	dba *ddb.Storage[Article],
+	dbk *ddb.Storage[Keyword],
 ) error {
 	if err := registerAuthor(db, "kleinrock", "Leonard Kleinrock"); err != nil {
 		return err
 	}
 
-	err := publishArticle(dba, dbk, "kleinrock",
+	err := publishArticle(db, dba, dbk, "kleinrock",
 		"queueing_sys_vol1",
 		"Queueing Systems: Volume I - Theory",
 		[]string{"queue", "theory"},
@@ -241,7 +262,7 @@ func articlesOfLeonardKleinrock(
 		return err
 	}
 
-	err = publishArticle(dba, dbk, "kleinrock",
+	err = publishArticle(db, dba, dbk, "kleinrock",
 		"queueing_sys_vol2",
 		"Queueing Systems: Volume II - Computer Applications",
 		[]string{"queue", "computer"},
@@ -266,29 +287,33 @@ func registerAuthor(db dynamo.KeyVal[Author], id, name string) error {
 }
 
 // As an author I want to publish an article to the system ...
+//
+// The article and its keywords are written atomically, the transaction
+// fails if the author is not registered.
 func publishArticle(
-	dba dynamo.KeyVal[Article],
-	dbk dynamo.KeyVal[Keyword],
+	db *ddb.Storage[Author],
+	dba *ddb.Storage[Article],
+	dbk *ddb.Storage[Keyword],
 	author, id, title string,
 	keywords []string,
 ) error {
 	log.Printf("==> publish: %s", title)
 
+	isAuthor := ddb.ClauseFor[Author, curie.IRI]("ID")
+
 	article := NewArticle(author, id, title)
-	if err := dba.Put(context.Background(), article); err != nil {
-		return err
+	tx := []ddb.TxWrite{
+		ddb.TxCheck(db, Author{ID: article.Author}, isAuthor.Exists()),
+		ddb.TxPut(dba, article),
 	}
 
 	for _, keyword := range keywords {
-		seq := NewKeyword(author, id, title, keyword)
-		for _, k := range seq {
-			if err := dbk.Put(context.Background(), k); err != nil {
-				return err
-			}
+		for _, k := range NewKeyword(author, id, title, keyword) {
+			tx = append(tx, ddb.TxPut(dbk, k))
 		}
 	}
 
-	return nil
+	return ddb.TransactWrite(context.Background(), tx...)
 }
 
 // stdio outputs query result
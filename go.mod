@@ -8,12 +8,16 @@ require (
 	github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue v1.15.17
 	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.37.1
 	github.com/aws/aws-sdk-go-v2/service/s3 v1.68.0
+	github.com/aws/smithy-go v1.22.1
 	github.com/fogfish/curie/v2 v2.0.1
 	github.com/fogfish/faults v0.2.0
 	github.com/fogfish/golem/hseq v1.2.0
 	github.com/fogfish/it v1.0.0
 	github.com/fogfish/it/v2 v2.0.2
 	github.com/fogfish/opts v0.0.4
+	github.com/fxamacker/cbor/v2 v2.7.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	go.etcd.io/bbolt v1.4.3
 )
 
 require (
@@ -33,7 +37,9 @@ require (
 	github.com/aws/aws-sdk-go-v2/service/sso v1.24.6 // indirect
 	github.com/aws/aws-sdk-go-v2/service/ssooidc v1.28.5 // indirect
 	github.com/aws/aws-sdk-go-v2/service/sts v1.33.1 // indirect
-	github.com/aws/smithy-go v1.22.1 // indirect
 	github.com/fogfish/golem/optics v0.13.1 // indirect
 	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/sys v0.29.0 // indirect
 )
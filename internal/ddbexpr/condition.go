@@ -0,0 +1,382 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddbexpr
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Item is attributes of DynamoDB item
+type Item = map[string]types.AttributeValue
+
+type predicate func(Item) bool
+
+// operand is either attribute of item or value of expression,
+// it returns false if the attribute is not defined.
+type operand func(Item) (types.AttributeValue, bool)
+
+// Condition evaluates condition expression (or key condition expression)
+// against the item. The empty expression is always true.
+//
+//	https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.OperatorsAndFunctions.html
+func Condition(
+	expr string,
+	names map[string]string,
+	values map[string]types.AttributeValue,
+	item Item,
+) (bool, error) {
+	if strings.TrimSpace(expr) == "" {
+		return true, nil
+	}
+
+	p, err := newParser(expr, names, values)
+	if err != nil {
+		return false, err
+	}
+
+	f, err := p.disjunction()
+	if err != nil {
+		return false, err
+	}
+
+	if err := p.eof(); err != nil {
+		return false, err
+	}
+
+	return f(item), nil
+}
+
+// disjunction := conjunction { OR conjunction }
+func (p *parser) disjunction() (predicate, error) {
+	f, err := p.conjunction()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("OR") {
+		a := f
+		b, err := p.conjunction()
+		if err != nil {
+			return nil, err
+		}
+		f = func(item Item) bool { return a(item) || b(item) }
+	}
+
+	return f, nil
+}
+
+// conjunction := negation { AND negation }
+func (p *parser) conjunction() (predicate, error) {
+	f, err := p.negation()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.keyword("AND") {
+		a := f
+		b, err := p.negation()
+		if err != nil {
+			return nil, err
+		}
+		f = func(item Item) bool { return a(item) && b(item) }
+	}
+
+	return f, nil
+}
+
+// negation := NOT negation | primary
+func (p *parser) negation() (predicate, error) {
+	if p.keyword("NOT") {
+		f, err := p.negation()
+		if err != nil {
+			return nil, err
+		}
+		return func(item Item) bool { return !f(item) }, nil
+	}
+
+	return p.primary()
+}
+
+// primary := ( condition ) | function | operand comparator operand
+//
+//	| operand BETWEEN operand AND operand
+//	| operand IN ( operand { , operand } )
+func (p *parser) primary() (predicate, error) {
+	if p.peek().kind == tLParen {
+		p.next()
+		f, err := p.disjunction()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tRParen); err != nil {
+			return nil, err
+		}
+		return f, nil
+	}
+
+	if fn, ok := p.function("attribute_exists", "attribute_not_exists", "attribute_type", "begins_with", "contains"); ok {
+		return p.predicate(fn)
+	}
+
+	a, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.keyword("BETWEEN"):
+		lo, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword("AND") {
+			return nil, fmt.Errorf("expected AND, got %q", p.peek().text)
+		}
+		hi, err := p.operand()
+		if err != nil {
+			return nil, err
+		}
+		return func(item Item) bool {
+			return compare(item, lo, a, func(c int) bool { return c <= 0 }) &&
+				compare(item, a, hi, func(c int) bool { return c <= 0 })
+		}, nil
+
+	case p.keyword("IN"):
+		if err := p.expect(tLParen); err != nil {
+			return nil, err
+		}
+		var seq []operand
+		for {
+			b, err := p.operand()
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, b)
+			if p.peek().kind != tComma {
+				break
+			}
+			p.next()
+		}
+		if err := p.expect(tRParen); err != nil {
+			return nil, err
+		}
+		return func(item Item) bool {
+			x, ok := a(item)
+			if !ok {
+				return false
+			}
+			for _, b := range seq {
+				if y, ok := b(item); ok && Equal(x, y) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+
+	t := p.next()
+	if t.kind != tOp {
+		return nil, fmt.Errorf("expected comparator, got %q", t.text)
+	}
+
+	b, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t.text {
+	case "=":
+		return func(item Item) bool {
+			x, okx := a(item)
+			y, oky := b(item)
+			return okx && oky && Equal(x, y)
+		}, nil
+	case "<>":
+		return func(item Item) bool {
+			x, okx := a(item)
+			y, oky := b(item)
+			return okx && oky && !Equal(x, y)
+		}, nil
+	case "<":
+		return func(item Item) bool { return compare(item, a, b, func(c int) bool { return c < 0 }) }, nil
+	case "<=":
+		return func(item Item) bool { return compare(item, a, b, func(c int) bool { return c <= 0 }) }, nil
+	case ">":
+		return func(item Item) bool { return compare(item, a, b, func(c int) bool { return c > 0 }) }, nil
+	case ">=":
+		return func(item Item) bool { return compare(item, a, b, func(c int) bool { return c >= 0 }) }, nil
+	}
+
+	return nil, fmt.Errorf("unsupported comparator %q", t.text)
+}
+
+func compare(item Item, a, b operand, f func(int) bool) bool {
+	x, okx := a(item)
+	y, oky := b(item)
+	if !okx || !oky {
+		return false
+	}
+
+	c, ok := Compare(x, y)
+	return ok && f(c)
+}
+
+// predicate := fn ( path [ , operand ] )
+func (p *parser) predicate(fn string) (predicate, error) {
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+
+	if fn == "attribute_exists" || fn == "attribute_not_exists" {
+		if err := p.expect(tRParen); err != nil {
+			return nil, err
+		}
+
+		exists := fn == "attribute_exists"
+		return func(item Item) bool {
+			_, has := item[path]
+			return has == exists
+		}, nil
+	}
+
+	if err := p.expect(tComma); err != nil {
+		return nil, err
+	}
+	b, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(tRParen); err != nil {
+		return nil, err
+	}
+
+	switch fn {
+	case "attribute_type":
+		return func(item Item) bool {
+			x, has := item[path]
+			y, ok := b(item)
+			s, isS := y.(*types.AttributeValueMemberS)
+			return has && ok && isS && typeOf(x) == s.Value
+		}, nil
+	case "begins_with":
+		return func(item Item) bool {
+			x, has := item[path]
+			y, ok := b(item)
+			return has && ok && beginsWith(x, y)
+		}, nil
+	default:
+		return func(item Item) bool {
+			x, has := item[path]
+			y, ok := b(item)
+			return has && ok && contains(x, y)
+		}, nil
+	}
+}
+
+// operand := size ( path ) | path | :value
+func (p *parser) operand() (operand, error) {
+	if _, ok := p.function("size"); ok {
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tRParen); err != nil {
+			return nil, err
+		}
+		return func(item Item) (types.AttributeValue, bool) {
+			x, has := item[path]
+			if !has {
+				return nil, false
+			}
+			n, ok := size(x)
+			if !ok {
+				return nil, false
+			}
+			return &types.AttributeValueMemberN{Value: fmt.Sprint(n)}, true
+		}, nil
+	}
+
+	if p.peek().kind == tValue {
+		val, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return func(Item) (types.AttributeValue, bool) { return val, true }, nil
+	}
+
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	return func(item Item) (types.AttributeValue, bool) {
+		x, has := item[path]
+		return x, has
+	}, nil
+}
+
+func beginsWith(a, b types.AttributeValue) bool {
+	switch x := a.(type) {
+	case *types.AttributeValueMemberS:
+		y, ok := b.(*types.AttributeValueMemberS)
+		return ok && strings.HasPrefix(x.Value, y.Value)
+	case *types.AttributeValueMemberB:
+		y, ok := b.(*types.AttributeValueMemberB)
+		return ok && bytes.HasPrefix(x.Value, y.Value)
+	}
+	return false
+}
+
+func contains(a, b types.AttributeValue) bool {
+	switch x := a.(type) {
+	case *types.AttributeValueMemberS:
+		y, ok := b.(*types.AttributeValueMemberS)
+		return ok && strings.Contains(x.Value, y.Value)
+	case *types.AttributeValueMemberB:
+		y, ok := b.(*types.AttributeValueMemberB)
+		return ok && bytes.Contains(x.Value, y.Value)
+	case *types.AttributeValueMemberSS:
+		y, ok := b.(*types.AttributeValueMemberS)
+		return ok && indexOf(x.Value, y.Value, func(a, b string) bool { return a == b }) != -1
+	case *types.AttributeValueMemberNS:
+		y, ok := b.(*types.AttributeValueMemberN)
+		return ok && indexOf(x.Value, y.Value, equalN) != -1
+	case *types.AttributeValueMemberBS:
+		y, ok := b.(*types.AttributeValueMemberB)
+		return ok && indexOf(x.Value, y.Value, bytes.Equal) != -1
+	case *types.AttributeValueMemberL:
+		return indexOf(x.Value, b, Equal) != -1
+	}
+	return false
+}
+
+func size(a types.AttributeValue) (int, bool) {
+	switch x := a.(type) {
+	case *types.AttributeValueMemberS:
+		return utf8.RuneCountInString(x.Value), true
+	case *types.AttributeValueMemberB:
+		return len(x.Value), true
+	case *types.AttributeValueMemberSS:
+		return len(x.Value), true
+	case *types.AttributeValueMemberNS:
+		return len(x.Value), true
+	case *types.AttributeValueMemberBS:
+		return len(x.Value), true
+	case *types.AttributeValueMemberL:
+		return len(x.Value), true
+	case *types.AttributeValueMemberM:
+		return len(x.Value), true
+	}
+	return 0, false
+}
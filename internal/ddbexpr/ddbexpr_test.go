@@ -0,0 +1,134 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddbexpr
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/it/v2"
+)
+
+func s(v string) types.AttributeValue { return &types.AttributeValueMemberS{Value: v} }
+func n(v string) types.AttributeValue { return &types.AttributeValueMemberN{Value: v} }
+
+var (
+	names = map[string]string{"#a": "a", "#b": "b", "#l": "l", "#ss": "ss"}
+	vals  = map[string]types.AttributeValue{
+		":s": s("abc"), ":p": s("ab"), ":n": n("10"), ":m": n("5"),
+		":l":  &types.AttributeValueMemberL{Value: []types.AttributeValue{n("3")}},
+		":ss": &types.AttributeValueMemberSS{Value: []string{"y", "z"}},
+		":t":  s("N"),
+	}
+	item = Item{
+		"a":  s("abc"),
+		"b":  n("7"),
+		"l":  &types.AttributeValueMemberL{Value: []types.AttributeValue{n("1"), n("2")}},
+		"ss": &types.AttributeValueMemberSS{Value: []string{"x", "y"}},
+	}
+)
+
+func TestCondition(t *testing.T) {
+	for expr, expect := range map[string]bool{
+		"":                                 true,
+		"#a = :s":                          true,
+		"#a <> :s":                         false,
+		"#b < :n":                          true,
+		"#b >= :n":                         false,
+		"#b BETWEEN :m AND :n":             true,
+		"#b IN (:m, :n)":                   false,
+		"attribute_exists(#a)":             true,
+		"attribute_not_exists(#a)":         false,
+		"attribute_not_exists(x)":          true,
+		"begins_with(#a, :p)":              true,
+		"contains(#ss, :p)":                false,
+		"attribute_type(#b, :t)":           true,
+		"size(#l) < :n":                    true,
+		"#a = :p or #b < :n":               true,
+		"#a = :p OR #b < :n and #a = :p":   false,
+		"(#a = :p or #b < :n) and #a = :s": true,
+		"NOT #a = :p":                      true,
+		"a = :s and b > :m":                true,
+	} {
+		val, err := Condition(expr, names, vals, item)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(val, expect),
+		)
+	}
+
+	for _, expr := range []string{"#a =", "#x = :s", "#a = :x", "#a ! :s", "(#a = :s"} {
+		_, err := Condition(expr, names, vals, item)
+		it.Then(t).ShouldNot(it.Nil(err))
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	val, err := Update(
+		"SET #a = :s, #b = #b + :n, #l = list_append(#l, :l), #x = if_not_exists(#x, :m) REMOVE #c ADD #ss :ss",
+		map[string]string{"#a": "a", "#b": "b", "#l": "l", "#ss": "ss", "#x": "x", "#c": "c"},
+		vals,
+		Item{"a": s("x"), "b": n("1.5"), "l": &types.AttributeValueMemberL{}, "c": s("c"),
+			"ss": &types.AttributeValueMemberSS{Value: []string{"x"}}},
+	)
+
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(val, Item{
+			"a":  s("abc"),
+			"b":  n("11.5"),
+			"l":  &types.AttributeValueMemberL{Value: []types.AttributeValue{n("3")}},
+			"x":  n("5"),
+			"ss": &types.AttributeValueMemberSS{Value: []string{"x", "y", "z"}},
+		}),
+	)
+
+	val, err = Update("DELETE #ss :ss", names, vals, item)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(val["ss"], types.AttributeValue(&types.AttributeValueMemberSS{Value: []string{"x"}})),
+	)
+}
+
+func TestProjection(t *testing.T) {
+	val, err := Projection("#a, b", names, item)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(val, Item{"a": s("abc"), "b": n("7")}),
+	)
+}
+
+func TestJSON(t *testing.T) {
+	val := Item{
+		"s":    s("abc"),
+		"n":    n("10"),
+		"b":    &types.AttributeValueMemberB{Value: []byte{1, 2}},
+		"bool": &types.AttributeValueMemberBOOL{Value: false},
+		"null": &types.AttributeValueMemberNULL{Value: true},
+		"ss":   &types.AttributeValueMemberSS{Value: []string{"x", "y"}},
+		"ns":   &types.AttributeValueMemberNS{Value: []string{"1", "2"}},
+		"bs":   &types.AttributeValueMemberBS{Value: [][]byte{{1}, {2}}},
+		"l":    &types.AttributeValueMemberL{Value: []types.AttributeValue{n("1"), s("a")}},
+		"el":   &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+		"m":    &types.AttributeValueMemberM{Value: Item{"a": s("b")}},
+	}
+
+	data, err := Marshal(val)
+	it.Then(t).Should(it.Nil(err))
+
+	got, err := Unmarshal(data)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(len(got), len(val)),
+	)
+
+	for k, v := range val {
+		it.Then(t).Should(it.True(Equal(got[k], v)))
+	}
+}
@@ -0,0 +1,154 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddbexpr
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// attribute value in DynamoDB JSON format
+//
+//	https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Programming.LowLevelAPI.html
+type attribute struct {
+	S    *string               `json:"S,omitempty"`
+	N    *string               `json:"N,omitempty"`
+	B    *[]byte               `json:"B,omitempty"`
+	BOOL *bool                 `json:"BOOL,omitempty"`
+	NULL *bool                 `json:"NULL,omitempty"`
+	SS   []string              `json:"SS,omitempty"`
+	NS   []string              `json:"NS,omitempty"`
+	BS   [][]byte              `json:"BS,omitempty"`
+	L    *[]attribute          `json:"L,omitempty"`
+	M    *map[string]attribute `json:"M,omitempty"`
+}
+
+// Marshal item to DynamoDB JSON format
+func Marshal(item Item) ([]byte, error) {
+	gen, err := toAttributes(item)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(gen)
+}
+
+// Unmarshal item from DynamoDB JSON format
+func Unmarshal(data []byte) (Item, error) {
+	var gen map[string]attribute
+	if err := json.Unmarshal(data, &gen); err != nil {
+		return nil, err
+	}
+
+	return fromAttributes(gen)
+}
+
+func toAttributes(item Item) (map[string]attribute, error) {
+	gen := make(map[string]attribute, len(item))
+	for k, v := range item {
+		a, err := toAttribute(v)
+		if err != nil {
+			return nil, err
+		}
+		gen[k] = a
+	}
+	return gen, nil
+}
+
+func toAttribute(v types.AttributeValue) (attribute, error) {
+	switch x := v.(type) {
+	case *types.AttributeValueMemberS:
+		return attribute{S: &x.Value}, nil
+	case *types.AttributeValueMemberN:
+		return attribute{N: &x.Value}, nil
+	case *types.AttributeValueMemberB:
+		return attribute{B: &x.Value}, nil
+	case *types.AttributeValueMemberBOOL:
+		return attribute{BOOL: &x.Value}, nil
+	case *types.AttributeValueMemberNULL:
+		return attribute{NULL: &x.Value}, nil
+	case *types.AttributeValueMemberSS:
+		return attribute{SS: x.Value}, nil
+	case *types.AttributeValueMemberNS:
+		return attribute{NS: x.Value}, nil
+	case *types.AttributeValueMemberBS:
+		return attribute{BS: x.Value}, nil
+	case *types.AttributeValueMemberL:
+		seq := make([]attribute, len(x.Value))
+		for i, e := range x.Value {
+			a, err := toAttribute(e)
+			if err != nil {
+				return attribute{}, err
+			}
+			seq[i] = a
+		}
+		return attribute{L: &seq}, nil
+	case *types.AttributeValueMemberM:
+		gen, err := toAttributes(x.Value)
+		if err != nil {
+			return attribute{}, err
+		}
+		return attribute{M: &gen}, nil
+	}
+
+	return attribute{}, fmt.Errorf("unsupported attribute %T", v)
+}
+
+func fromAttributes(gen map[string]attribute) (Item, error) {
+	item := make(Item, len(gen))
+	for k, a := range gen {
+		v, err := fromAttribute(a)
+		if err != nil {
+			return nil, err
+		}
+		item[k] = v
+	}
+	return item, nil
+}
+
+func fromAttribute(a attribute) (types.AttributeValue, error) {
+	switch {
+	case a.S != nil:
+		return &types.AttributeValueMemberS{Value: *a.S}, nil
+	case a.N != nil:
+		return &types.AttributeValueMemberN{Value: *a.N}, nil
+	case a.B != nil:
+		return &types.AttributeValueMemberB{Value: *a.B}, nil
+	case a.BOOL != nil:
+		return &types.AttributeValueMemberBOOL{Value: *a.BOOL}, nil
+	case a.NULL != nil:
+		return &types.AttributeValueMemberNULL{Value: *a.NULL}, nil
+	case a.SS != nil:
+		return &types.AttributeValueMemberSS{Value: a.SS}, nil
+	case a.NS != nil:
+		return &types.AttributeValueMemberNS{Value: a.NS}, nil
+	case a.BS != nil:
+		return &types.AttributeValueMemberBS{Value: a.BS}, nil
+	case a.L != nil:
+		seq := make([]types.AttributeValue, len(*a.L))
+		for i, e := range *a.L {
+			v, err := fromAttribute(e)
+			if err != nil {
+				return nil, err
+			}
+			seq[i] = v
+		}
+		return &types.AttributeValueMemberL{Value: seq}, nil
+	case a.M != nil:
+		gen, err := fromAttributes(*a.M)
+		if err != nil {
+			return nil, err
+		}
+		return &types.AttributeValueMemberM{Value: gen}, nil
+	}
+
+	return nil, fmt.Errorf("undefined attribute")
+}
@@ -0,0 +1,196 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+// Package ddbexpr evaluates DynamoDB expressions (condition, key condition,
+// update and projection) against items kept in memory. It supports the
+// subset of the grammar used by the library.
+//
+//	https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.html
+package ddbexpr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type kind int
+
+const (
+	tEOF kind = iota
+	tName
+	tValue
+	tOp
+	tLParen
+	tRParen
+	tComma
+)
+
+type token struct {
+	kind kind
+	text string
+}
+
+func lex(expr string) ([]token, error) {
+	var seq []token
+
+	for i := 0; i < len(expr); {
+		c := rune(expr[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(':
+			seq = append(seq, token{tLParen, "("})
+			i++
+		case c == ')':
+			seq = append(seq, token{tRParen, ")"})
+			i++
+		case c == ',':
+			seq = append(seq, token{tComma, ","})
+			i++
+		case c == '=' || c == '+' || c == '-':
+			seq = append(seq, token{tOp, string(c)})
+			i++
+		case c == '<' || c == '>':
+			op := string(c)
+			if i+1 < len(expr) && (expr[i+1] == '=' || (c == '<' && expr[i+1] == '>')) {
+				op += string(expr[i+1])
+			}
+			seq = append(seq, token{tOp, op})
+			i += len(op)
+		case c == ':':
+			j := i + 1
+			for j < len(expr) && isNameChar(rune(expr[j])) {
+				j++
+			}
+			seq = append(seq, token{tValue, expr[i:j]})
+			i = j
+		case c == '#' || isNameChar(c):
+			j := i + 1
+			for j < len(expr) && isNameChar(rune(expr[j])) {
+				j++
+			}
+			seq = append(seq, token{tName, expr[i:j]})
+			i = j
+		default:
+			return nil, fmt.Errorf("unexpected symbol %q at %d", c, i)
+		}
+	}
+
+	return append(seq, token{tEOF, ""}), nil
+}
+
+func isNameChar(c rune) bool {
+	return c == '_' || c == '.' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// parser is a cursor over tokens of expression
+type parser struct {
+	seq    []token
+	pos    int
+	names  map[string]string
+	values map[string]types.AttributeValue
+}
+
+func newParser(
+	expr string,
+	names map[string]string,
+	values map[string]types.AttributeValue,
+) (*parser, error) {
+	seq, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &parser{seq: seq, names: names, values: values}, nil
+}
+
+func (p *parser) peek() token { return p.seq[p.pos] }
+
+func (p *parser) next() token {
+	t := p.seq[p.pos]
+	if t.kind != tEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) eof() error {
+	if t := p.peek(); t.kind != tEOF {
+		return fmt.Errorf("unexpected %q", t.text)
+	}
+	return nil
+}
+
+func (p *parser) expect(k kind) error {
+	if t := p.next(); t.kind != k {
+		return fmt.Errorf("unexpected %q", t.text)
+	}
+	return nil
+}
+
+// keyword consumes the keyword, it is case insensitive
+func (p *parser) keyword(kw string) bool {
+	t := p.peek()
+	if t.kind == tName && strings.EqualFold(t.text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// function consumes name of the function followed by open parenthesis
+func (p *parser) function(fn ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tName || p.seq[p.pos+1].kind != tLParen {
+		return "", false
+	}
+
+	for _, f := range fn {
+		if strings.EqualFold(t.text, f) {
+			p.pos += 2
+			return f, true
+		}
+	}
+
+	return "", false
+}
+
+// path consumes attribute name, the placeholder is resolved
+func (p *parser) path() (string, error) {
+	t := p.next()
+	if t.kind != tName {
+		return "", fmt.Errorf("expected attribute name, got %q", t.text)
+	}
+
+	if !strings.HasPrefix(t.text, "#") {
+		return t.text, nil
+	}
+
+	name, has := p.names[t.text]
+	if !has {
+		return "", fmt.Errorf("undefined attribute name %s", t.text)
+	}
+	return name, nil
+}
+
+// value consumes placeholder of the value and resolves it
+func (p *parser) value() (types.AttributeValue, error) {
+	t := p.next()
+	if t.kind != tValue {
+		return nil, fmt.Errorf("expected attribute value, got %q", t.text)
+	}
+
+	val, has := p.values[t.text]
+	if !has {
+		return nil, fmt.Errorf("undefined attribute value %s", t.text)
+	}
+	return val, nil
+}
@@ -0,0 +1,409 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddbexpr
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// action applies the update to new item, values are read from the old one
+type action func(old, new Item) error
+
+// Update evaluates update expression against the item. It returns a new
+// item, the original one is not modified.
+//
+//	https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Expressions.UpdateExpressions.html
+func Update(
+	expr string,
+	names map[string]string,
+	values map[string]types.AttributeValue,
+	item Item,
+) (Item, error) {
+	p, err := newParser(expr, names, values)
+	if err != nil {
+		return nil, err
+	}
+
+	var seq []action
+	for p.peek().kind != tEOF {
+		var f func() (action, error)
+		switch {
+		case p.keyword("SET"):
+			f = p.actionSet
+		case p.keyword("REMOVE"):
+			f = p.actionRemove
+		case p.keyword("ADD"):
+			f = p.actionAdd
+		case p.keyword("DELETE"):
+			f = p.actionDelete
+		default:
+			return nil, fmt.Errorf("unexpected %q", p.peek().text)
+		}
+
+		for {
+			a, err := f()
+			if err != nil {
+				return nil, err
+			}
+			seq = append(seq, a)
+			if p.peek().kind != tComma {
+				break
+			}
+			p.next()
+		}
+	}
+
+	out := maps.Clone(item)
+	if out == nil {
+		out = Item{}
+	}
+
+	for _, a := range seq {
+		if err := a(item, out); err != nil {
+			return nil, err
+		}
+	}
+
+	return out, nil
+}
+
+// SET path = value
+func (p *parser) actionSet() (action, error) {
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.next(); t.kind != tOp || t.text != "=" {
+		return nil, fmt.Errorf("expected =, got %q", t.text)
+	}
+
+	f, err := p.setValue()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(old, new Item) error {
+		val, err := f(old)
+		if err != nil {
+			return err
+		}
+		new[path] = val
+		return nil
+	}, nil
+}
+
+type setter func(Item) (types.AttributeValue, error)
+
+// value := operand [ (+|-) operand ]
+func (p *parser) setValue() (setter, error) {
+	a, err := p.setOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	t := p.peek()
+	if t.kind != tOp || (t.text != "+" && t.text != "-") {
+		return a, nil
+	}
+	p.next()
+
+	b, err := p.setOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(item Item) (types.AttributeValue, error) {
+		x, err := a(item)
+		if err != nil {
+			return nil, err
+		}
+		y, err := b(item)
+		if err != nil {
+			return nil, err
+		}
+		return arithmetic(t.text, x, y)
+	}, nil
+}
+
+// operand := if_not_exists ( path , value ) | list_append ( value , value ) | path | :value
+func (p *parser) setOperand() (setter, error) {
+	if fn, ok := p.function("if_not_exists", "list_append"); ok {
+		if fn == "if_not_exists" {
+			path, err := p.path()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tComma); err != nil {
+				return nil, err
+			}
+			f, err := p.setValue()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(tRParen); err != nil {
+				return nil, err
+			}
+			return func(item Item) (types.AttributeValue, error) {
+				if x, has := item[path]; has {
+					return x, nil
+				}
+				return f(item)
+			}, nil
+		}
+
+		a, err := p.setValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tComma); err != nil {
+			return nil, err
+		}
+		b, err := p.setValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(tRParen); err != nil {
+			return nil, err
+		}
+		return func(item Item) (types.AttributeValue, error) {
+			x, err := a(item)
+			if err != nil {
+				return nil, err
+			}
+			y, err := b(item)
+			if err != nil {
+				return nil, err
+			}
+			lx, okx := x.(*types.AttributeValueMemberL)
+			ly, oky := y.(*types.AttributeValueMemberL)
+			if !okx || !oky {
+				return nil, fmt.Errorf("list_append requires lists")
+			}
+			seq := append(append([]types.AttributeValue{}, lx.Value...), ly.Value...)
+			return &types.AttributeValueMemberL{Value: seq}, nil
+		}, nil
+	}
+
+	if p.peek().kind == tValue {
+		val, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		return func(Item) (types.AttributeValue, error) { return val, nil }, nil
+	}
+
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	return func(item Item) (types.AttributeValue, error) {
+		x, has := item[path]
+		if !has {
+			return nil, fmt.Errorf("attribute %s is not defined", path)
+		}
+		return x, nil
+	}, nil
+}
+
+func arithmetic(op string, a, b types.AttributeValue) (types.AttributeValue, error) {
+	x, okx := a.(*types.AttributeValueMemberN)
+	y, oky := b.(*types.AttributeValueMemberN)
+	if !okx || !oky {
+		return nil, fmt.Errorf("arithmetic %s requires numbers", op)
+	}
+
+	nx, okx := number(x.Value)
+	ny, oky := number(y.Value)
+	if !okx || !oky {
+		return nil, fmt.Errorf("invalid number")
+	}
+
+	if op == "+" {
+		return &types.AttributeValueMemberN{Value: formatN(nx.Add(nx, ny))}, nil
+	}
+	return &types.AttributeValueMemberN{Value: formatN(nx.Sub(nx, ny))}, nil
+}
+
+// REMOVE path
+func (p *parser) actionRemove() (action, error) {
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(old, new Item) error {
+		delete(new, path)
+		return nil
+	}, nil
+}
+
+// ADD path :value
+func (p *parser) actionAdd() (action, error) {
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(old, new Item) error {
+		x, has := new[path]
+		if !has {
+			new[path] = val
+			return nil
+		}
+
+		switch x := x.(type) {
+		case *types.AttributeValueMemberN:
+			v, err := arithmetic("+", x, val)
+			if err != nil {
+				return err
+			}
+			new[path] = v
+		case *types.AttributeValueMemberSS:
+			y, ok := val.(*types.AttributeValueMemberSS)
+			if !ok {
+				return fmt.Errorf("ADD requires string set")
+			}
+			new[path] = &types.AttributeValueMemberSS{Value: union(x.Value, y.Value, func(a, b string) bool { return a == b })}
+		case *types.AttributeValueMemberNS:
+			y, ok := val.(*types.AttributeValueMemberNS)
+			if !ok {
+				return fmt.Errorf("ADD requires number set")
+			}
+			new[path] = &types.AttributeValueMemberNS{Value: union(x.Value, y.Value, equalN)}
+		case *types.AttributeValueMemberBS:
+			y, ok := val.(*types.AttributeValueMemberBS)
+			if !ok {
+				return fmt.Errorf("ADD requires binary set")
+			}
+			new[path] = &types.AttributeValueMemberBS{Value: union(x.Value, y.Value, bytes.Equal)}
+		default:
+			return fmt.Errorf("ADD is not supported for %s", typeOf(x))
+		}
+		return nil
+	}, nil
+}
+
+// DELETE path :value
+func (p *parser) actionDelete() (action, error) {
+	path, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(old, new Item) error {
+		x, has := new[path]
+		if !has {
+			return nil
+		}
+
+		var n int
+		switch x := x.(type) {
+		case *types.AttributeValueMemberSS:
+			y, ok := val.(*types.AttributeValueMemberSS)
+			if !ok {
+				return fmt.Errorf("DELETE requires string set")
+			}
+			seq := minus(x.Value, y.Value, func(a, b string) bool { return a == b })
+			new[path], n = &types.AttributeValueMemberSS{Value: seq}, len(seq)
+		case *types.AttributeValueMemberNS:
+			y, ok := val.(*types.AttributeValueMemberNS)
+			if !ok {
+				return fmt.Errorf("DELETE requires number set")
+			}
+			seq := minus(x.Value, y.Value, equalN)
+			new[path], n = &types.AttributeValueMemberNS{Value: seq}, len(seq)
+		case *types.AttributeValueMemberBS:
+			y, ok := val.(*types.AttributeValueMemberBS)
+			if !ok {
+				return fmt.Errorf("DELETE requires binary set")
+			}
+			seq := minus(x.Value, y.Value, bytes.Equal)
+			new[path], n = &types.AttributeValueMemberBS{Value: seq}, len(seq)
+		default:
+			return fmt.Errorf("DELETE is not supported for %s", typeOf(x))
+		}
+
+		// empty sets are not allowed
+		if n == 0 {
+			delete(new, path)
+		}
+		return nil
+	}, nil
+}
+
+func union[A any](a, b []A, eq func(A, A) bool) []A {
+	seq := append([]A{}, a...)
+	for _, x := range b {
+		if indexOf(seq, x, eq) == -1 {
+			seq = append(seq, x)
+		}
+	}
+	return seq
+}
+
+func minus[A any](a, b []A, eq func(A, A) bool) []A {
+	seq := []A{}
+	for _, x := range a {
+		if indexOf(b, x, eq) == -1 {
+			seq = append(seq, x)
+		}
+	}
+	return seq
+}
+
+// Projection selects attributes of the item defined by projection
+// expression. The empty expression selects all attributes.
+func Projection(expr string, names map[string]string, item Item) (Item, error) {
+	if strings.TrimSpace(expr) == "" || item == nil {
+		return item, nil
+	}
+
+	p, err := newParser(expr, names, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	out := Item{}
+	for {
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		if x, has := item[path]; has {
+			out[path] = x
+		}
+
+		if p.peek().kind != tComma {
+			break
+		}
+		p.next()
+	}
+
+	if err := p.eof(); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,156 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddbexpr
+
+import (
+	"bytes"
+	"math/big"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Compare scalar attributes of same type (string, number, binary)
+func Compare(a, b types.AttributeValue) (int, bool) {
+	switch x := a.(type) {
+	case *types.AttributeValueMemberS:
+		if y, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(x.Value, y.Value), true
+		}
+	case *types.AttributeValueMemberN:
+		if y, ok := b.(*types.AttributeValueMemberN); ok {
+			nx, okx := number(x.Value)
+			ny, oky := number(y.Value)
+			if okx && oky {
+				return nx.Cmp(ny), true
+			}
+		}
+	case *types.AttributeValueMemberB:
+		if y, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(x.Value, y.Value), true
+		}
+	}
+
+	return 0, false
+}
+
+// Equal compares attributes deeply
+func Equal(a, b types.AttributeValue) bool {
+	switch x := a.(type) {
+	case *types.AttributeValueMemberS, *types.AttributeValueMemberN, *types.AttributeValueMemberB:
+		c, ok := Compare(a, b)
+		return ok && c == 0
+	case *types.AttributeValueMemberBOOL:
+		y, ok := b.(*types.AttributeValueMemberBOOL)
+		return ok && x.Value == y.Value
+	case *types.AttributeValueMemberNULL:
+		y, ok := b.(*types.AttributeValueMemberNULL)
+		return ok && x.Value == y.Value
+	case *types.AttributeValueMemberSS:
+		y, ok := b.(*types.AttributeValueMemberSS)
+		return ok && sameSet(x.Value, y.Value, func(a, b string) bool { return a == b })
+	case *types.AttributeValueMemberNS:
+		y, ok := b.(*types.AttributeValueMemberNS)
+		return ok && sameSet(x.Value, y.Value, equalN)
+	case *types.AttributeValueMemberBS:
+		y, ok := b.(*types.AttributeValueMemberBS)
+		return ok && sameSet(x.Value, y.Value, bytes.Equal)
+	case *types.AttributeValueMemberL:
+		y, ok := b.(*types.AttributeValueMemberL)
+		if !ok || len(x.Value) != len(y.Value) {
+			return false
+		}
+		for i := range x.Value {
+			if !Equal(x.Value[i], y.Value[i]) {
+				return false
+			}
+		}
+		return true
+	case *types.AttributeValueMemberM:
+		y, ok := b.(*types.AttributeValueMemberM)
+		if !ok || len(x.Value) != len(y.Value) {
+			return false
+		}
+		for k, v := range x.Value {
+			if w, has := y.Value[k]; !has || !Equal(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+func sameSet[A any](a, b []A, eq func(A, A) bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, x := range a {
+		if indexOf(b, x, eq) == -1 {
+			return false
+		}
+	}
+	return true
+}
+
+func indexOf[A any](seq []A, x A, eq func(A, A) bool) int {
+	for i, y := range seq {
+		if eq(x, y) {
+			return i
+		}
+	}
+	return -1
+}
+
+func number(s string) (*big.Rat, bool) {
+	return new(big.Rat).SetString(s)
+}
+
+func equalN(a, b string) bool {
+	c, ok := Compare(&types.AttributeValueMemberN{Value: a}, &types.AttributeValueMemberN{Value: b})
+	return ok && c == 0
+}
+
+// formats number using shortest decimal notation
+func formatN(x *big.Rat) string {
+	if x.IsInt() {
+		return x.Num().String()
+	}
+
+	s := strings.TrimRight(x.FloatString(38), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// typeOf returns DynamoDB type descriptor of the attribute
+func typeOf(a types.AttributeValue) string {
+	switch a.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberM:
+		return "M"
+	}
+	return ""
+}
@@ -308,3 +308,39 @@ func (mock BatchGetItem) BatchGetItem(ctx context.Context, params *dynamodb.Batc
 
 	return mock.ReturnVal, nil
 }
+
+type TransactWriteItems struct {
+	Mock[dynamodb.TransactWriteItemsInput, dynamodb.TransactWriteItemsOutput]
+}
+
+func (mock TransactWriteItems) TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	if mock.ExpectVal != nil {
+		if !reflect.DeepEqual(mock.ExpectVal, params) {
+			return nil, fmt.Errorf("unexpected input")
+		}
+	}
+
+	if mock.ReturnErr != nil {
+		return nil, mock.ReturnErr
+	}
+
+	return mock.ReturnVal, nil
+}
+
+type TransactGetItems struct {
+	Mock[dynamodb.TransactGetItemsInput, dynamodb.TransactGetItemsOutput]
+}
+
+func (mock TransactGetItems) TransactGetItems(ctx context.Context, params *dynamodb.TransactGetItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	if mock.ExpectVal != nil {
+		if !reflect.DeepEqual(mock.ExpectVal, params) {
+			return nil, fmt.Errorf("unexpected input")
+		}
+	}
+
+	if mock.ReturnErr != nil {
+		return nil, mock.ReturnErr
+	}
+
+	return mock.ReturnVal, nil
+}
@@ -0,0 +1,73 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+// Package iterator implements lazy sequences over paginated storage I/O.
+package iterator
+
+import (
+	"context"
+	"iter"
+
+	"github.com/fogfish/dynamo/v3"
+)
+
+// Page reads a single page of the collection
+type Page[T dynamo.Thing] func(context.Context, ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error)
+
+// Seq lazily follows cursors of paginated I/O. The sequence stops on
+// the first error, which is yielded together with zero value of T.
+func Seq[T dynamo.Thing](ctx context.Context, page Page[T], opts []interface{ MatcherOpt(T) }) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var undefined T
+
+		maxItems := -1
+		base := make([]interface{ MatcherOpt(T) }, 0, len(opts)+1)
+		for _, opt := range opts {
+			switch v := opt.(type) {
+			case interface{ MaxItems() int }:
+				maxItems = v.MaxItems()
+			case dynamo.Thing:
+				// cursor is replaced at each page
+			default:
+				base = append(base, opt)
+			}
+		}
+
+		req := opts
+		count := 0
+		for maxItems < 0 || count < maxItems {
+			if err := ctx.Err(); err != nil {
+				yield(undefined, err)
+				return
+			}
+
+			seq, cursor, err := page(ctx, req...)
+			if err != nil {
+				yield(undefined, err)
+				return
+			}
+
+			for _, x := range seq {
+				if maxItems >= 0 && count >= maxItems {
+					return
+				}
+
+				if !yield(x, nil) {
+					return
+				}
+				count++
+			}
+
+			if cursor == nil {
+				return
+			}
+
+			req = append(base, cursor)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file implements evaluation of ddb conditions
+//
+
+package kv
+
+import (
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+)
+
+// CheckCondition checks conditions (e.g. ddb.ClauseFor) against the existing
+// item, the nil item does not exist. TTL options are rejected.
+func CheckCondition[T dynamo.Thing](
+	key dynamo.Thing,
+	item ddbexpr.Item,
+	names map[string]string,
+	values map[string]types.AttributeValue,
+	opts []interface{ WriterOpt(T) },
+) error {
+	if err := CheckTTL(opts); err != nil {
+		return err
+	}
+
+	var seq []string
+	for _, opt := range opts {
+		if ap, ok := opt.(interface {
+			Apply(map[string]string, map[string]types.AttributeValue) string
+		}); ok {
+			if expr := ap.Apply(names, values); expr != "" {
+				seq = append(seq, "("+expr+")")
+			}
+		}
+	}
+
+	if len(seq) == 0 {
+		return nil
+	}
+
+	ok, err := ddbexpr.Condition(strings.Join(seq, " and "), names, values, item)
+	if err != nil {
+		return errInvalidExpression.New(err)
+	}
+
+	if !ok {
+		return errPreConditionFailed(key, item != nil)
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+// Package kv implements semantic of DynamoDB over generic items
+// (ddbexpr.Item): evaluation of conditions (ddb.ClauseFor), update
+// expressions (ddb.UpdateFor) and pattern matching over partitions.
+// It is shared by embedded storages (mem, bolt), which only keep items.
+package kv
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"github.com/fogfish/faults"
+)
+
+const (
+	errInvalidKey        = faults.Type("invalid key")
+	errInvalidEntity     = faults.Type("invalid entity")
+	errInvalidExpression = faults.Type("invalid expression")
+	errInvalidLimit      = faults.Safe1[int32]("invalid limit %d, it must be positive")
+	errUnsupportedOpt    = faults.Safe1[string]("unsupported option %s")
+)
+
+// errPreConditionFailed, the item exists but does not match the condition
+// is the conflict, the missing item is gone.
+func errPreConditionFailed(thing dynamo.Thing, exists bool) error {
+	return &dynamo.PreConditionFailedError{Thing: thing, Failure: dynamo.Failure(exists, !exists)}
+}
+
+// Encode entity to generic representation, nil attributes are discarded
+func Encode[T dynamo.Thing](entity T) (ddbexpr.Item, error) {
+	if entity.HashKey() == "" {
+		return nil, errInvalidKey.New(nil)
+	}
+
+	gen, err := attributevalue.MarshalMap(entity)
+	if err != nil {
+		return nil, errInvalidEntity.New(err)
+	}
+
+	// No Update is applied for nil attributes
+	for k, v := range gen {
+		if _, ok := v.(*types.AttributeValueMemberNULL); ok {
+			delete(gen, k)
+		}
+	}
+
+	return gen, nil
+}
+
+// Decode generic representation to entity, nil item is zero entity
+func Decode[T dynamo.Thing](item ddbexpr.Item) (T, error) {
+	var val T
+	if item == nil {
+		return val, nil
+	}
+
+	if err := attributevalue.UnmarshalMap(item, &val); err != nil {
+		return *new(T), errInvalidEntity.New(err)
+	}
+
+	return val, nil
+}
+
+// KeyOnly extracts key attributes from generic representation of entity.
+// Key attributes are named by dynamodbav tags of struct fields, which
+// define HashKey and SortKey of the entity.
+func KeyOnly[T dynamo.Thing](entity T, gen ddbexpr.Item) ddbexpr.Item {
+	item := ddbexpr.Item{}
+	for _, k := range keyAttrsOf(entity) {
+		if v, has := gen[k]; has {
+			item[k] = v
+		}
+	}
+	return item
+}
+
+// names of key attributes, the string field defines the key if its change
+// changes HashKey or SortKey of the entity.
+func keyAttrsOf[T dynamo.Thing](entity T) []string {
+	val := reflect.Indirect(reflect.ValueOf(entity))
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	seq := make([]string, 0, 2)
+	for i := 0; i < val.NumField(); i++ {
+		f := val.Type().Field(i)
+		if !f.IsExported() || f.Anonymous || f.Type.Kind() != reflect.String {
+			continue
+		}
+
+		attr := attrNameOf(f)
+		if attr == "" {
+			continue
+		}
+
+		probe := reflect.New(val.Type())
+		probe.Elem().Set(val)
+		probe.Elem().Field(i).SetString(val.Field(i).String() + "\x00")
+
+		var key dynamo.Thing
+		if reflect.TypeOf(entity).Kind() == reflect.Pointer {
+			key = probe.Interface().(T)
+		} else {
+			key = probe.Elem().Interface().(T)
+		}
+
+		if key.HashKey() != entity.HashKey() || key.SortKey() != entity.SortKey() {
+			seq = append(seq, attr)
+		}
+	}
+
+	return seq
+}
+
+// name of attribute defined by dynamodbav tag, same as attributevalue does
+func attrNameOf(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("dynamodbav"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	default:
+		return name
+	}
+}
@@ -0,0 +1,53 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package kv_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"github.com/fogfish/dynamo/v3/internal/kv"
+	"github.com/fogfish/it/v2"
+)
+
+type person struct {
+	Prefix curie.IRI `dynamodbav:"prefix,omitempty"`
+	Suffix curie.IRI `dynamodbav:"suffix,omitempty"`
+	Name   string    `dynamodbav:"name,omitempty"`
+}
+
+func (p person) HashKey() curie.IRI { return p.Prefix }
+func (p person) SortKey() curie.IRI { return p.Suffix }
+
+func TestKeyOnly(t *testing.T) {
+	// the attribute is not a key even if its value equals to the key
+	val := person{Prefix: "a", Suffix: "b", Name: "a"}
+
+	gen, err := kv.Encode(val)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(kv.KeyOnly(val, gen), ddbexpr.Item{
+			"prefix": &types.AttributeValueMemberS{Value: "a"},
+			"suffix": &types.AttributeValueMemberS{Value: "b"},
+		}),
+	)
+}
+
+func TestQuery(t *testing.T) {
+	_, err := kv.NewQuery[person](person{Prefix: "a"},
+		[]interface{ MatcherOpt(person) }{dynamo.Limit[person](0)},
+	)
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	_, err = kv.NewQuery[person](person{}, nil)
+	it.Then(t).ShouldNot(it.Nil(err))
+}
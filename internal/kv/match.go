@@ -0,0 +1,167 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file implements pattern matching over partition
+//
+
+package kv
+
+import (
+	"iter"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+)
+
+// sort key attribute used to evaluate key conditions (ddb.SortKey)
+const sortKeyAttr = "__sortKey__"
+
+// Query is the pattern matching over partition, it follows semantic of
+// DynamoDB: sort key prefix (`begins_with`) or key condition (ddb.SortKey),
+// filter expressions (ddb.Filter), Limit, Cursor and Reverse order.
+type Query[T dynamo.Thing] struct {
+	HashKey curie.IRI    // partition
+	Prefix  curie.IRI    // prefix of sort key
+	Cursor  dynamo.Thing // items are matched after the cursor, if defined
+	Reverse bool         // items are matched in descending order of sort key
+
+	limit   int
+	keyExpr string
+	filter  []string
+	names   map[string]string
+	values  map[string]types.AttributeValue
+}
+
+// NewQuery builds the query for the pattern
+func NewQuery[T dynamo.Thing](key dynamo.Thing, opts []interface{ MatcherOpt(T) }) (*Query[T], error) {
+	if key.HashKey() == "" {
+		return nil, errInvalidKey.New(nil)
+	}
+
+	if err := CheckTTL(opts); err != nil {
+		return nil, err
+	}
+
+	q := &Query[T]{
+		HashKey: key.HashKey(),
+		Prefix:  key.SortKey(),
+		limit:   -1,
+		names:   map[string]string{},
+		values:  map[string]types.AttributeValue{},
+	}
+
+	if q.Prefix == "_" {
+		q.Prefix = ""
+	}
+
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case interface{ Limit() int32 }:
+			if v.Limit() <= 0 {
+				return nil, errInvalidLimit.New(nil, v.Limit())
+			}
+			q.limit = int(v.Limit())
+		case interface{ Reverse() bool }:
+			q.Reverse = v.Reverse()
+		case interface {
+			KeyCondition(string, map[string]types.AttributeValue) string
+		}:
+			// Key condition replaces the sort key of the pattern
+			q.Prefix = ""
+			q.keyExpr = v.KeyCondition(sortKeyAttr, q.values)
+		case interface {
+			FilterExpression(map[string]string, map[string]types.AttributeValue) string
+		}:
+			if expr := v.FilterExpression(q.names, q.values); expr != "" {
+				q.filter = append(q.filter, "("+expr+")")
+			}
+		case dynamo.Thing:
+			q.Cursor = v
+		}
+	}
+
+	return q, nil
+}
+
+// Eval evaluates items of the partition, the sequence yields sort key and
+// the item in the order of the query. It returns matched entities and
+// the cursor to the next page.
+func (q *Query[T]) Eval(seq iter.Seq2[curie.IRI, func() (ddbexpr.Item, error)]) ([]T, interface{ MatcherOpt(T) }, error) {
+	page := make([]T, 0)
+	evaluated := 0
+	var last curie.IRI
+	for sortKey, item := range seq {
+		if q.Cursor != nil && !q.after(sortKey) {
+			continue
+		}
+
+		if !strings.HasPrefix(string(sortKey), string(q.Prefix)) {
+			continue
+		}
+
+		if q.keyExpr != "" {
+			ok, err := ddbexpr.Condition(q.keyExpr, q.names, q.values,
+				ddbexpr.Item{sortKeyAttr: &types.AttributeValueMemberS{Value: string(sortKey)}},
+			)
+			if err != nil {
+				return nil, nil, errInvalidExpression.New(err)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		// Limit is the number of evaluated items, the page is incomplete
+		// if there are more items to evaluate.
+		if q.limit > 0 && evaluated == q.limit {
+			return page, dynamo.Cursor[T](cursor{q.HashKey, last}), nil
+		}
+		evaluated++
+		last = sortKey
+
+		val, err := item()
+		if err != nil {
+			return nil, nil, errInvalidEntity.New(err)
+		}
+
+		if len(q.filter) > 0 {
+			ok, err := ddbexpr.Condition(strings.Join(q.filter, " and "), q.names, q.values, val)
+			if err != nil {
+				return nil, nil, errInvalidExpression.New(err)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		obj, err := Decode[T](val)
+		if err != nil {
+			return nil, nil, err
+		}
+		page = append(page, obj)
+	}
+
+	return page, nil, nil
+}
+
+// after checks if sort key follows the cursor in the order of iteration
+func (q *Query[T]) after(sortKey curie.IRI) bool {
+	if q.Reverse {
+		return sortKey < q.Cursor.SortKey()
+	}
+	return sortKey > q.Cursor.SortKey()
+}
+
+type cursor struct{ hashKey, sortKey curie.IRI }
+
+func (c cursor) HashKey() curie.IRI { return c.hashKey }
+func (c cursor) SortKey() curie.IRI { return c.sortKey }
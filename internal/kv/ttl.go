@@ -0,0 +1,30 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file implements checks of time-to-live options (dynamo.TTL,
+// dynamo.SkipExpired), embedded storages do not expire items.
+//
+
+package kv
+
+import "time"
+
+// CheckTTL rejects time-to-live options instead of ignoring them, otherwise
+// the storage returns items the caller asked to hide.
+func CheckTTL[O any](opts []O) error {
+	for _, opt := range opts {
+		switch any(opt).(type) {
+		case interface{ ExpiresAt() time.Time }:
+			return errUnsupportedOpt.New(nil, "TTL")
+		case interface{ SkipExpired() bool }:
+			return errUnsupportedOpt.New(nil, "SkipExpired")
+		}
+	}
+	return nil
+}
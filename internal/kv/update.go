@@ -0,0 +1,74 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file implements updates of items
+//
+
+package kv
+
+import (
+	"maps"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"github.com/fogfish/dynamo/v3/internal/updateexpr"
+)
+
+// Update merges defined attributes of entity (gen) into the existing item,
+// the item is created if it does not exist.
+func Update[T dynamo.Thing](entity T, gen, item ddbexpr.Item, opts []interface{ WriterOpt(T) }) (ddbexpr.Item, error) {
+	err := CheckCondition(entity, item,
+		map[string]string{}, map[string]types.AttributeValue{}, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := maps.Clone(item)
+	if updated == nil {
+		updated = ddbexpr.Item{}
+	}
+	maps.Copy(updated, gen)
+
+	return updated, nil
+}
+
+// UpdateWith applies update expression (see ddb.UpdateFor) to the existing
+// item, the item is created from the key of entity if it does not exist.
+func UpdateWith[T dynamo.Thing](entity T, expression updateexpr.Expression, item ddbexpr.Item, opts []interface{ WriterOpt(T) }) (ddbexpr.Item, error) {
+	// names and values of condition are isolated from the update expression
+	names := maps.Clone(expression.Names)
+	if names == nil {
+		names = map[string]string{}
+	}
+	values := maps.Clone(expression.Values)
+	if values == nil {
+		values = map[string]types.AttributeValue{}
+	}
+
+	if err := CheckCondition(entity, item, names, values, opts); err != nil {
+		return nil, err
+	}
+
+	// Non existing item is created from the key
+	if item == nil {
+		gen, err := Encode(entity)
+		if err != nil {
+			return nil, err
+		}
+		item = KeyOnly(entity, gen)
+	}
+
+	updated, err := ddbexpr.Update(expression.Expression, names, values, item)
+	if err != nil {
+		return nil, errInvalidExpression.New(err)
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,257 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package s3test
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+)
+
+// Bucket is in-memory mock of S3 bucket, it keeps objects and their headers
+type Bucket struct {
+	sync.Mutex
+	Objects  map[string]*Object
+	versions int
+}
+
+// Object of the bucket
+type Object struct {
+	Body            []byte
+	ContentType     *string
+	ContentEncoding *string
+	CacheControl    *string
+	StorageClass    types.StorageClass
+	Metadata        map[string]string
+	Tags            map[string]string
+	ETag            string
+	VersionId       string
+	LastModified    time.Time
+	Expires         *time.Time
+}
+
+// NewBucket creates empty bucket
+func NewBucket() *Bucket {
+	return &Bucket{Objects: map[string]*Object{}}
+}
+
+func (b *Bucket) GetObject(ctx context.Context, input *s3.GetObjectInput, opts ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	obj, has := b.Objects[aws.ToString(input.Key)]
+	if !has {
+		return nil, &types.NoSuchKey{}
+	}
+
+	val := &s3.GetObjectOutput{
+		Body:            io.NopCloser(bytes.NewReader(obj.Body)),
+		ContentLength:   aws.Int64(int64(len(obj.Body))),
+		ContentType:     obj.ContentType,
+		ContentEncoding: obj.ContentEncoding,
+		CacheControl:    obj.CacheControl,
+		StorageClass:    obj.StorageClass,
+		Metadata:        obj.Metadata,
+		ETag:            aws.String(obj.ETag),
+		LastModified:    aws.Time(obj.LastModified),
+	}
+	if obj.VersionId != "" {
+		val.VersionId = aws.String(obj.VersionId)
+	}
+	if len(obj.Tags) != 0 {
+		val.TagCount = aws.Int32(int32(len(obj.Tags)))
+	}
+	if obj.Expires != nil {
+		val.ExpiresString = aws.String(obj.Expires.UTC().Format(http.TimeFormat))
+	}
+
+	return val, nil
+}
+
+func (b *Bucket) PutObject(ctx context.Context, input *s3.PutObjectInput, opts ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	body, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	ifMatch := headersOf(input, opts).Get("If-Match")
+
+	tagging, err := url.ParseQuery(aws.ToString(input.Tagging))
+	if err != nil {
+		return nil, &smithy.GenericAPIError{Code: "InvalidArgument", Message: err.Error()}
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	key := aws.ToString(input.Key)
+	if err := b.precondition(key, ifMatch, aws.ToString(input.IfNoneMatch)); err != nil {
+		return nil, err
+	}
+
+	obj := &Object{
+		Body:            body,
+		ContentType:     input.ContentType,
+		ContentEncoding: input.ContentEncoding,
+		CacheControl:    input.CacheControl,
+		StorageClass:    input.StorageClass,
+		Metadata:        input.Metadata,
+		ETag:            fmt.Sprintf("\"%x\"", md5.Sum(body)),
+		LastModified:    time.Now().UTC(),
+		Expires:         input.Expires,
+	}
+	if len(tagging) != 0 {
+		obj.Tags = make(map[string]string, len(tagging))
+		for k := range tagging {
+			obj.Tags[k] = tagging.Get(k)
+		}
+	}
+	b.versions++
+	obj.VersionId = strconv.Itoa(b.versions)
+	b.Objects[key] = obj
+
+	return &s3.PutObjectOutput{ETag: aws.String(obj.ETag), VersionId: aws.String(obj.VersionId)}, nil
+}
+
+func (b *Bucket) GetObjectTagging(ctx context.Context, input *s3.GetObjectTaggingInput, opts ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	obj, has := b.Objects[aws.ToString(input.Key)]
+	if !has {
+		return nil, &types.NoSuchKey{}
+	}
+
+	keys := make([]string, 0, len(obj.Tags))
+	for k := range obj.Tags {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	tags := make([]types.Tag, len(keys))
+	for i, k := range keys {
+		tags[i] = types.Tag{Key: aws.String(k), Value: aws.String(obj.Tags[k])}
+	}
+
+	return &s3.GetObjectTaggingOutput{TagSet: tags, VersionId: aws.String(obj.VersionId)}, nil
+}
+
+func (b *Bucket) DeleteObject(ctx context.Context, input *s3.DeleteObjectInput, opts ...func(*s3.Options)) (*s3.DeleteObjectOutput, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	key := aws.ToString(input.Key)
+	if err := b.precondition(key, aws.ToString(input.IfMatch), ""); err != nil {
+		return nil, err
+	}
+
+	delete(b.Objects, key)
+
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+func (b *Bucket) ListObjectsV2(ctx context.Context, input *s3.ListObjectsV2Input, opts ...func(*s3.Options)) (*s3.ListObjectsV2Output, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	keys := make([]string, 0)
+	for key := range b.Objects {
+		if strings.HasPrefix(key, aws.ToString(input.Prefix)) && key > aws.ToString(input.StartAfter) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	limit := int(aws.ToInt32(input.MaxKeys))
+	if limit <= 0 {
+		limit = 1000
+	}
+
+	var next *string
+	if len(keys) > limit {
+		keys = keys[:limit]
+		next = aws.String(keys[limit-1])
+	}
+
+	seq := make([]types.Object, len(keys))
+	for i, key := range keys {
+		obj := b.Objects[key]
+		seq[i] = types.Object{
+			Key:          aws.String(key),
+			Size:         aws.Int64(int64(len(obj.Body))),
+			ETag:         aws.String(obj.ETag),
+			LastModified: aws.Time(obj.LastModified),
+		}
+	}
+
+	return &s3.ListObjectsV2Output{
+		KeyCount:              aws.Int32(int32(len(seq))),
+		Contents:              seq,
+		NextContinuationToken: next,
+	}, nil
+}
+
+// checks conditional headers of the request against the object
+func (b *Bucket) precondition(key, ifMatch, ifNoneMatch string) error {
+	obj, has := b.Objects[key]
+
+	switch {
+	case ifMatch != "" && !has:
+		return &types.NoSuchKey{}
+	case ifMatch != "" && ifMatch != obj.ETag:
+		return &smithy.GenericAPIError{Code: "PreconditionFailed", Message: "At least one of the pre-conditions you specified did not hold"}
+	case ifNoneMatch == "*" && has:
+		return &smithy.GenericAPIError{Code: "PreconditionFailed", Message: "At least one of the pre-conditions you specified did not hold"}
+	}
+
+	return nil
+}
+
+// headersOf evaluates options of the request, it returns HTTP headers
+// injected by them into the request (e.g. smithyhttp.SetHeaderValue)
+func headersOf(input any, opts []func(*s3.Options)) http.Header {
+	var conf s3.Options
+	for _, opt := range opts {
+		opt(&conf)
+	}
+
+	stack := middleware.NewStack("s3test", smithyhttp.NewStackRequest)
+	for _, f := range conf.APIOptions {
+		if err := f(stack); err != nil {
+			return http.Header{}
+		}
+	}
+
+	headers := http.Header{}
+	handler := middleware.HandlerFunc(func(ctx context.Context, in any) (any, middleware.Metadata, error) {
+		if req, ok := in.(*smithyhttp.Request); ok {
+			headers = req.Header
+		}
+		return nil, middleware.Metadata{}, nil
+	})
+	middleware.DecorateHandler(handler, stack).Handle(context.Background(), input)
+
+	return headers
+}
@@ -6,7 +6,8 @@
 // https://github.com/fogfish/dynamo
 //
 
-package s3
+// Package schema implements reflection utilities over struct types
+package schema
 
 import (
 	"reflect"
@@ -18,13 +19,14 @@ import (
 /*
 Schema is utility that merges two struct
 */
-type schema[T dynamo.Thing] struct{ hseq.Seq[T] }
+type Schema[T dynamo.Thing] struct{ hseq.Seq[T] }
 
-func newSchema[T dynamo.Thing]() *schema[T] {
-	return &schema[T]{hseq.New[T]()}
+func New[T dynamo.Thing]() *Schema[T] {
+	return &Schema[T]{hseq.New[T]()}
 }
 
-func (schema schema[T]) Merge(a, b T) (c T) {
+// Merge struct a and b, non-zero fields of a takes precedence over b
+func (schema Schema[T]) Merge(a, b T) (c T) {
 	va := reflect.ValueOf(a)
 	if va.Kind() == reflect.Pointer {
 		va = va.Elem()
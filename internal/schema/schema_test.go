@@ -6,7 +6,7 @@
 // https://github.com/fogfish/dynamo
 //
 
-package s3
+package schema
 
 import (
 	"testing"
@@ -37,13 +37,13 @@ func TestMerge(t *testing.T) {
 	}
 
 	t.Run("Values", func(t *testing.T) {
-		schema := newSchema[dynamotest.Person]()
+		schema := New[dynamotest.Person]()
 		it.Ok(t).
 			If(schema.Merge(a, b)).Should().Equal(c)
 	})
 
 	t.Run("Pointers", func(t *testing.T) {
-		schema := newSchema[*dynamotest.Person]()
+		schema := New[*dynamotest.Person]()
 		it.Ok(t).
 			If(schema.Merge(&a, &b)).Should().Equal(&c)
 	})
@@ -0,0 +1,29 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+// Package updateexpr gives embedded storages (mem, bolt) access to update
+// expressions built by ddb.UpdateFor. The expression is not exported by ddb
+// package, the package registers the accessor at init.
+package updateexpr
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Expression is update expression together with attribute names and values
+type Expression struct {
+	// Entity, which defines key of the updated item
+	Entity any
+
+	Expression string
+	Names      map[string]string
+	Values     map[string]types.AttributeValue
+}
+
+// Of reads the expression from ddb.UpdateItemExpression, it is defined by ddb
+var Of func(any) Expression
@@ -0,0 +1,449 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+// Package kvtest implements conformance test suite for storages that
+// implements dynamo.KeyVal interface. Third-party backends prove the
+// compatibility with the library by running the suite against live
+// instance of the storage:
+//
+//	func TestConformance(t *testing.T) {
+//		kvtest.TestKeyVal(t, func() dynamo.KeyVal[kvtest.Person] {
+//			return mybackend.New[kvtest.Person]()
+//		})
+//	}
+package kvtest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/service/ddb"
+	"github.com/fogfish/faults"
+	"github.com/fogfish/it/v2"
+	"github.com/fogfish/opts"
+)
+
+// Person is a type used by the suite, the storage factory has to be
+// able to persist it.
+type Person struct {
+	Prefix  curie.IRI `dynamodbav:"prefix,omitempty" json:"prefix,omitempty"`
+	Suffix  curie.IRI `dynamodbav:"suffix,omitempty" json:"suffix,omitempty"`
+	Name    string    `dynamodbav:"name,omitempty" json:"name,omitempty"`
+	Age     int       `dynamodbav:"age,omitempty" json:"age,omitempty"`
+	Address string    `dynamodbav:"address,omitempty" json:"address,omitempty"`
+}
+
+func (p Person) HashKey() curie.IRI { return p.Prefix }
+func (p Person) SortKey() curie.IRI { return p.Suffix }
+
+// key is a generic dynamo.Thing used by MatchKey
+type key struct{ hashKey, sortKey curie.IRI }
+
+func (k key) HashKey() curie.IRI { return k.hashKey }
+func (k key) SortKey() curie.IRI { return k.sortKey }
+
+var (
+	name = ddb.ClauseFor[Person, string]("Name")
+	age  = ddb.ClauseFor[Person, int]("Age")
+)
+
+// Option type to configure the suite
+type Option = opts.Option[Options]
+
+// Config Options
+type Options struct {
+	conditions bool
+}
+
+var (
+	// Enables tests of conditional writes (see ddb.ClauseFor), default is true.
+	// Disable it for storages that do not support conditional expressions,
+	// the suite checks that such storages reject them.
+	WithConditions = opts.ForName[Options, bool]("conditions")
+)
+
+// Factory creates a new empty instance of storage
+type Factory func() dynamo.KeyVal[Person]
+
+// TestKeyVal runs all tests of the suite
+func TestKeyVal(t *testing.T, factory Factory, opt ...Option) {
+	t.Helper()
+
+	conf := Options{conditions: true}
+	if err := opts.Apply(&conf, opt); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Get", func(t *testing.T) { TestGet(t, factory) })
+	t.Run("Put", func(t *testing.T) { TestPut(t, factory) })
+	t.Run("Remove", func(t *testing.T) { TestRemove(t, factory) })
+	t.Run("Update", func(t *testing.T) { TestUpdate(t, factory) })
+	t.Run("Match", func(t *testing.T) { TestMatch(t, factory) })
+	if conf.conditions {
+		t.Run("Condition", func(t *testing.T) { TestCondition(t, factory) })
+	} else {
+		t.Run("ConditionUnsupported", func(t *testing.T) { TestConditionUnsupported(t, factory) })
+	}
+	t.Run("TTLUnsupported", func(t *testing.T) { TestTTLUnsupported(t, factory) })
+}
+
+func fixtureKey() Person {
+	return Person{
+		Prefix: curie.IRI("kvtest:person"),
+		Suffix: curie.IRI("1"),
+	}
+}
+
+func fixtureVal() Person {
+	return Person{
+		Prefix:  curie.IRI("kvtest:person"),
+		Suffix:  curie.IRI("1"),
+		Name:    "Verner Pleishner",
+		Age:     64,
+		Address: "Blumenstrasse 14, Berne, 3013",
+	}
+}
+
+func fixturePatch() Person {
+	return Person{
+		Prefix: curie.IRI("kvtest:person"),
+		Suffix: curie.IRI("1"),
+		Age:    65,
+	}
+}
+
+func fixtureSeq(n int) []Person {
+	seq := make([]Person, n)
+	for i := 0; i < n; i++ {
+		seq[i] = Person{
+			Prefix: curie.IRI("kvtest:seq"),
+			Suffix: curie.IRI("s:" + strconv.Itoa(i)),
+			Name:   "Person " + strconv.Itoa(i),
+			Age:    i,
+		}
+	}
+	return seq
+}
+
+func setup(t *testing.T, factory Factory, seq ...Person) dynamo.KeyVal[Person] {
+	t.Helper()
+
+	db := factory()
+	for _, x := range seq {
+		if err := db.Put(context.Background(), x); err != nil {
+			t.Fatalf("unable to setup fixture: %v", err)
+		}
+	}
+
+	return db
+}
+
+// TestGet checks read of existing and missing items
+func TestGet(t *testing.T, factory Factory) {
+	t.Helper()
+
+	t.Run("Success", func(t *testing.T) {
+		db := setup(t, factory, fixtureVal())
+
+		val, err := db.Get(context.Background(), fixtureKey())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(val, fixtureVal()),
+		)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		db := setup(t, factory)
+
+		val, err := db.Get(context.Background(), fixtureKey())
+		it.Then(t).Should(
+			it.True(faults.IsNotFound(err)),
+			it.Equiv(val, Person{}),
+		)
+	})
+}
+
+// TestPut checks write of items
+func TestPut(t *testing.T, factory Factory) {
+	t.Helper()
+
+	t.Run("Create", func(t *testing.T) {
+		db := setup(t, factory)
+
+		err := db.Put(context.Background(), fixtureVal())
+		it.Then(t).Should(it.Nil(err))
+
+		val, err := db.Get(context.Background(), fixtureKey())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(val, fixtureVal()),
+		)
+	})
+
+	t.Run("Overwrite", func(t *testing.T) {
+		db := setup(t, factory, fixtureVal())
+
+		err := db.Put(context.Background(), fixturePatch())
+		it.Then(t).Should(it.Nil(err))
+
+		val, err := db.Get(context.Background(), fixtureKey())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(val, fixturePatch()),
+		)
+	})
+}
+
+// TestRemove checks removal of items
+func TestRemove(t *testing.T, factory Factory) {
+	t.Helper()
+
+	db := setup(t, factory, fixtureVal())
+
+	val, err := db.Remove(context.Background(), fixtureKey())
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(val, fixtureVal()),
+	)
+
+	_, err = db.Get(context.Background(), fixtureKey())
+	it.Then(t).Should(it.True(faults.IsNotFound(err)))
+}
+
+// TestUpdate checks merge semantic of update: defined attributes of patch
+// overwrite existing one, other attributes are preserved.
+func TestUpdate(t *testing.T, factory Factory) {
+	t.Helper()
+
+	t.Run("Merge", func(t *testing.T) {
+		db := setup(t, factory, fixtureVal())
+
+		expect := fixtureVal()
+		expect.Age = fixturePatch().Age
+
+		val, err := db.Update(context.Background(), fixturePatch())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(val, expect),
+		)
+
+		val, err = db.Get(context.Background(), fixtureKey())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(val, expect),
+		)
+	})
+
+	t.Run("Create", func(t *testing.T) {
+		db := setup(t, factory)
+
+		val, err := db.Update(context.Background(), fixturePatch())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(val, fixturePatch()),
+		)
+
+		val, err = db.Get(context.Background(), fixtureKey())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(val, fixturePatch()),
+		)
+	})
+}
+
+// TestMatch checks pattern matching and paging
+func TestMatch(t *testing.T, factory Factory) {
+	t.Helper()
+
+	seq := fixtureSeq(5)
+	other := []Person{
+		{Prefix: "kvtest:seq", Suffix: "x:0", Name: "Other"},
+		{Prefix: "kvtest:other", Suffix: "s:0", Name: "Other"},
+	}
+
+	t.Run("SortKeyPrefix", func(t *testing.T) {
+		db := setup(t, factory, append(seq, other...)...)
+
+		val, _, err := db.Match(context.Background(), Person{Prefix: "kvtest:seq", Suffix: "s:"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Seq(val).Equal(seq...),
+		)
+	})
+
+	t.Run("HashKey", func(t *testing.T) {
+		db := setup(t, factory, append(seq, other...)...)
+
+		val, _, err := db.Match(context.Background(), Person{Prefix: "kvtest:seq"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Seq(val).Equal(append(seq, other[0])...),
+		)
+	})
+
+	t.Run("MatchKey", func(t *testing.T) {
+		db := setup(t, factory, append(seq, other...)...)
+
+		val, _, err := db.MatchKey(context.Background(), key{"kvtest:seq", "s:"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Seq(val).Equal(seq...),
+		)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		db := setup(t, factory, append(seq, other...)...)
+
+		val, _, err := db.Match(context.Background(), Person{Prefix: "kvtest:none"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(val), 0),
+		)
+	})
+
+	t.Run("Paging", func(t *testing.T) {
+		db := setup(t, factory, append(seq, other...)...)
+
+		var (
+			val    []Person
+			cursor interface{ MatcherOpt(Person) }
+		)
+
+		// storage might return empty page at the end of sequence
+		for i := 0; i <= len(seq); i++ {
+			opts := []interface{ MatcherOpt(Person) }{dynamo.Limit[Person](2)}
+			if cursor != nil {
+				opts = append(opts, cursor)
+			}
+
+			page, next, err := db.Match(context.Background(), Person{Prefix: "kvtest:seq", Suffix: "s:"}, opts...)
+			it.Then(t).Should(
+				it.Nil(err),
+				it.True(len(page) <= 2),
+			)
+
+			val = append(val, page...)
+			cursor = next
+			if cursor == nil {
+				break
+			}
+		}
+
+		it.Then(t).Should(
+			it.True(cursor == nil),
+			it.Seq(val).Equal(seq...),
+		)
+	})
+}
+
+// TestCondition checks that conditional writes (see ddb.ClauseFor) fails
+// with precondition error.
+func TestCondition(t *testing.T, factory Factory) {
+	t.Helper()
+
+	t.Run("PutSuccess", func(t *testing.T) {
+		db := setup(t, factory)
+
+		err := db.Put(context.Background(), fixtureVal(), name.NotExists())
+		it.Then(t).Should(it.Nil(err))
+	})
+
+	t.Run("PutFailure", func(t *testing.T) {
+		db := setup(t, factory, fixtureVal())
+
+		err := db.Put(context.Background(), fixtureVal(), name.NotExists())
+		it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+	})
+
+	t.Run("RemoveFailure", func(t *testing.T) {
+		db := setup(t, factory, fixtureVal())
+
+		_, err := db.Remove(context.Background(), fixtureKey(), name.Eq("Unknown"))
+		it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+
+		_, err = db.Get(context.Background(), fixtureKey())
+		it.Then(t).Should(it.Nil(err))
+	})
+
+	t.Run("UpdateSuccess", func(t *testing.T) {
+		db := setup(t, factory, fixtureVal())
+
+		val, err := db.Update(context.Background(), fixturePatch(), age.Eq(fixtureVal().Age))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(val.Age, fixturePatch().Age),
+		)
+	})
+
+	t.Run("UpdateFailure", func(t *testing.T) {
+		db := setup(t, factory, fixtureVal())
+
+		_, err := db.Update(context.Background(), fixturePatch(), age.Eq(1))
+		it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+
+		val, err := db.Get(context.Background(), fixtureKey())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(val, fixtureVal()),
+		)
+	})
+}
+
+// TestConditionUnsupported checks that storage rejects conditional writes,
+// the item is not modified.
+func TestConditionUnsupported(t *testing.T, factory Factory) {
+	t.Helper()
+
+	db := setup(t, factory, fixtureVal())
+
+	err := db.Put(context.Background(), fixtureVal(), name.NotExists())
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	_, err = db.Remove(context.Background(), fixtureKey(), name.Eq("Unknown"))
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	_, err = db.Update(context.Background(), fixturePatch(), age.Eq(1))
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	val, err := db.Get(context.Background(), fixtureKey())
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(val, fixtureVal()),
+	)
+}
+
+// TestTTLUnsupported checks that storage without time-to-live support (e.g.
+// ddb storage without ddb.WithTTLAttribute) rejects TTL options instead of
+// ignoring them.
+func TestTTLUnsupported(t *testing.T, factory Factory) {
+	t.Helper()
+
+	db := setup(t, factory, fixtureVal())
+
+	err := db.Put(context.Background(), fixtureVal(), dynamo.TTL[Person](time.Hour))
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	_, err = db.Update(context.Background(), fixturePatch(), dynamo.ExpiresAt[Person](time.Now()))
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	_, err = db.Get(context.Background(), fixtureKey(), dynamo.SkipExpired[Person]())
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	_, _, err = db.Match(context.Background(), fixtureKey(), dynamo.SkipExpired[Person]())
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	val, err := db.Get(context.Background(), fixtureKey())
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(val, fixtureVal()),
+	)
+}
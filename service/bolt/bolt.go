@@ -0,0 +1,92 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+// Package bolt implements persistent storage on the embedded ordered
+// key-value store (bbolt), it is intended for single-process and edge
+// deployments. The storage follows semantic of DynamoDB: pattern matching
+// with `begins_with` is an ordered range scan, pagination with Limit and
+// Cursor, evaluation of conditions (ddb.ClauseFor) and update expressions
+// (ddb.UpdateFor).
+package bolt
+
+import (
+	"time"
+
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/opts"
+	"go.etcd.io/bbolt"
+)
+
+// Storage type
+type Storage[T dynamo.Thing] struct {
+	Options
+	owned     bool
+	bucket    []byte
+	undefined T
+}
+
+// Must constraint for api factory
+func Must[T dynamo.Thing](keyval *Storage[T], err error) *Storage[T] {
+	if err != nil {
+		panic(err)
+	}
+
+	return keyval
+}
+
+// New creates instance of storage, entities are kept in the bucket
+func New[T dynamo.Thing](bucket string, opt ...Option) (*Storage[T], error) {
+	conf := optsDefault()
+	if err := opts.Apply(&conf, opt); err != nil {
+		return nil, err
+	}
+
+	if bucket == "" {
+		return nil, errUndefinedBucket.New(nil)
+	}
+
+	owned := conf.service == nil && conf.file != ""
+	if owned {
+		db, err := bbolt.Open(conf.file, 0600, &bbolt.Options{Timeout: time.Second})
+		if err != nil {
+			return nil, errServiceIO.New(err)
+		}
+		conf.service = db
+	}
+
+	if err := conf.checkRequired(); err != nil {
+		return nil, err
+	}
+
+	err := conf.service.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(bucket))
+		return err
+	})
+	if err != nil {
+		// the database is closed if it is opened by the storage
+		if owned {
+			conf.service.Close()
+		}
+		return nil, errServiceIO.New(err)
+	}
+
+	return &Storage[T]{
+		Options: conf,
+		owned:   owned,
+		bucket:  []byte(bucket),
+	}, nil
+}
+
+// Close the underlying database if it is opened by the storage (WithFile),
+// the database shared with WithBolt is closed by the application.
+func (db *Storage[T]) Close() error {
+	if !db.owned {
+		return nil
+	}
+	return db.service.Close()
+}
@@ -0,0 +1,232 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package bolt_test
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/kvtest"
+	"github.com/fogfish/dynamo/v3/service/bolt"
+	"github.com/fogfish/dynamo/v3/service/ddb"
+	"github.com/fogfish/faults"
+	"github.com/fogfish/it/v2"
+	"go.etcd.io/bbolt"
+)
+
+type Person struct {
+	Prefix curie.IRI `dynamodbav:"prefix,omitempty"`
+	Suffix curie.IRI `dynamodbav:"suffix,omitempty"`
+	Name   string    `dynamodbav:"name,omitempty"`
+	Age    int       `dynamodbav:"age,omitempty"`
+}
+
+func (p Person) HashKey() curie.IRI { return p.Prefix }
+func (p Person) SortKey() curie.IRI { return p.Suffix }
+
+var (
+	age       = ddb.ClauseFor[Person, int]("Age")
+	updateAge = ddb.UpdateFor[Person, int]("Age")
+)
+
+func fixture(t *testing.T) *bolt.Storage[Person] {
+	t.Helper()
+
+	db := bolt.Must(bolt.New[Person]("person",
+		bolt.WithFile(filepath.Join(t.TempDir(), "test.db")),
+	))
+	t.Cleanup(func() { db.Close() })
+
+	for i := 0; i < 5; i++ {
+		db.Put(context.Background(),
+			Person{Prefix: "p", Suffix: curie.IRI("s:" + strconv.Itoa(i)), Name: "n" + strconv.Itoa(i), Age: i},
+		)
+	}
+	db.Put(context.Background(), Person{Prefix: "p", Suffix: "x:0"})
+	db.Put(context.Background(), Person{Prefix: "pp", Suffix: "s:0"})
+
+	return db
+}
+
+func TestNew(t *testing.T) {
+	_, err := bolt.New[Person]("person")
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	_, err = bolt.New[Person]("", bolt.WithFile(filepath.Join(t.TempDir(), "test.db")))
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	_, err = bolt.New[Person]("person", bolt.WithFile(filepath.Join(t.TempDir(), "none", "test.db")))
+	it.Then(t).ShouldNot(it.Nil(err))
+}
+
+func TestConformance(t *testing.T) {
+	file, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	it.Then(t).Should(it.Nil(err))
+	defer file.Close()
+
+	n := 0
+	kvtest.TestKeyVal(t, func() dynamo.KeyVal[kvtest.Person] {
+		n++
+		return bolt.Must(bolt.New[kvtest.Person]("kv"+strconv.Itoa(n), bolt.WithBolt(file)))
+	})
+}
+
+func TestPersistence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "test.db")
+
+	db := bolt.Must(bolt.New[Person]("person", bolt.WithFile(file)))
+	err := db.Put(context.Background(), Person{Prefix: "p", Suffix: "s", Name: "x"})
+	it.Then(t).Should(it.Nil(err))
+	it.Then(t).Should(it.Nil(db.Close()))
+
+	db = bolt.Must(bolt.New[Person]("person", bolt.WithFile(file)))
+	defer db.Close()
+
+	val, err := db.Get(context.Background(), Person{Prefix: "p", Suffix: "s"})
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(val.Name, "x"),
+	)
+}
+
+func TestCondition(t *testing.T) {
+	db := fixture(t)
+
+	_, err := db.Update(context.Background(), Person{Prefix: "p", Suffix: "s:1", Name: "y"}, age.Gt(10))
+	it.Then(t).Should(
+		it.True(faults.IsPreConditionFailed(err)),
+		it.True(faults.IsConflict(err)),
+	)
+
+	_, err = db.Remove(context.Background(), Person{Prefix: "p", Suffix: "s:9"}, age.Exists())
+	it.Then(t).Should(
+		it.True(faults.IsPreConditionFailed(err)),
+		it.True(faults.IsGone(err)),
+	)
+}
+
+func TestUpdateWith(t *testing.T) {
+	db := fixture(t)
+
+	val, err := db.UpdateWith(context.Background(),
+		ddb.Updater(Person{Prefix: "p", Suffix: "s:2"}, updateAge.Inc(10)),
+		age.Eq(2),
+	)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(val.Age, 12),
+		it.Equal(val.Name, "n2"),
+	)
+
+	// Non existing item is created from the key
+	val, err = db.UpdateWith(context.Background(),
+		ddb.Updater(Person{Prefix: "p", Suffix: "s:9", Name: "x"}, updateAge.SetNotExists(1)),
+	)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(val, Person{Prefix: "p", Suffix: "s:9", Age: 1}),
+	)
+}
+
+func TestMatch(t *testing.T) {
+	db := fixture(t)
+
+	t.Run("Partition", func(t *testing.T) {
+		seq, cur, err := db.Match(context.Background(), Person{Prefix: "p"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 6),
+			it.True(cur == nil),
+		)
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "p", Suffix: "s:"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 5),
+			it.Equal(seq[0].Suffix, "s:0"),
+			it.Equal(seq[4].Suffix, "s:4"),
+		)
+	})
+
+	t.Run("Reverse", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "p", Suffix: "s:"},
+			dynamo.Reverse[Person](),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 5),
+			it.Equal(seq[0].Suffix, "s:4"),
+			it.Equal(seq[4].Suffix, "s:0"),
+		)
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		for _, reverse := range []bool{false, true} {
+			var (
+				val    []Person
+				cursor interface{ MatcherOpt(Person) }
+			)
+			for {
+				opts := []interface{ MatcherOpt(Person) }{dynamo.Limit[Person](2)}
+				if reverse {
+					opts = append(opts, dynamo.Reverse[Person]())
+				}
+				if cursor != nil {
+					opts = append(opts, cursor)
+				}
+				seq, cur, err := db.Match(context.Background(), Person{Prefix: "p", Suffix: "s:"}, opts...)
+				it.Then(t).Should(it.Nil(err))
+				val = append(val, seq...)
+				if cursor = cur; cursor == nil {
+					break
+				}
+			}
+
+			it.Then(t).Should(it.Equal(len(val), 5))
+			if reverse {
+				it.Then(t).Should(it.Equal(val[0].Suffix, "s:4"))
+			} else {
+				it.Then(t).Should(it.Equal(val[0].Suffix, "s:0"))
+			}
+		}
+	})
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+		for _, limit := range []int32{0, -1} {
+			_, _, err := db.Match(context.Background(), Person{Prefix: "p"}, dynamo.Limit[Person](limit))
+			it.Then(t).ShouldNot(it.Nil(err))
+		}
+	})
+
+	t.Run("KeyCondition", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "p"},
+			ddb.SortKey[Person]().Between("s:1", "s:3"),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 3),
+		)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "p", Suffix: "s:"},
+			ddb.Filter(age.Ge(3)),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 2),
+		)
+	})
+}
@@ -0,0 +1,72 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package bolt
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"go.etcd.io/bbolt"
+)
+
+// separator of hash and sort keys, it co-locates items of the partition
+// and orders them by sort key.
+const separator = 0x00
+
+// encodes composite key ⟨hash, sort⟩ ⟼ hash 0x00 sort
+func encodeKey(key dynamo.Thing) ([]byte, error) {
+	hkey := string(key.HashKey())
+	if hkey == "" || strings.IndexByte(hkey, separator) != -1 {
+		return nil, errInvalidKey.New(nil)
+	}
+
+	return append(append([]byte(hkey), separator), key.SortKey()...), nil
+}
+
+// prefix of keys for the pattern ⟨hash, sort⟩
+func encodePrefix(hashKey, sortKey curie.IRI) []byte {
+	return append(append([]byte(hashKey), separator), sortKey...)
+}
+
+func decodeKey(key []byte) cursor {
+	hkey, skey, _ := bytes.Cut(key, []byte{separator})
+	return cursor{hashKey: curie.IRI(hkey), sortKey: curie.IRI(skey)}
+}
+
+// get item by key from the bucket, the nil item is returned if it does not exist
+func (db *Storage[T]) get(bucket *bbolt.Bucket, key []byte) (ddbexpr.Item, error) {
+	val := bucket.Get(key)
+	if val == nil {
+		return nil, nil
+	}
+
+	item, err := ddbexpr.Unmarshal(val)
+	if err != nil {
+		return nil, errInvalidEntity.New(err)
+	}
+
+	return item, nil
+}
+
+// put item to the bucket
+func (db *Storage[T]) put(bucket *bbolt.Bucket, key []byte, item ddbexpr.Item) error {
+	val, err := ddbexpr.Marshal(item)
+	if err != nil {
+		return errInvalidEntity.New(err)
+	}
+
+	if err := bucket.Put(key, val); err != nil {
+		return errServiceIO.New(err)
+	}
+
+	return nil
+}
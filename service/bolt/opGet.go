@@ -0,0 +1,44 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package bolt
+
+import (
+	"context"
+
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"github.com/fogfish/dynamo/v3/internal/kv"
+	"go.etcd.io/bbolt"
+)
+
+// Get item from storage
+func (db *Storage[T]) Get(ctx context.Context, key T, opts ...interface{ GetterOpt(T) }) (T, error) {
+	if err := kv.CheckTTL(opts); err != nil {
+		return db.undefined, err
+	}
+
+	gen, err := encodeKey(key)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	var item ddbexpr.Item
+	err = db.service.View(func(tx *bbolt.Tx) (err error) {
+		item, err = db.get(tx.Bucket(db.bucket), gen)
+		return
+	})
+	if err != nil {
+		return db.undefined, err
+	}
+
+	if item == nil {
+		return db.undefined, errNotFound(nil, key)
+	}
+
+	return kv.Decode[T](item)
+}
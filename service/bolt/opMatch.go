@@ -0,0 +1,149 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"iter"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"github.com/fogfish/dynamo/v3/internal/iterator"
+	"github.com/fogfish/dynamo/v3/internal/kv"
+	"go.etcd.io/bbolt"
+)
+
+// MatchKey applies a pattern matching to elements in the storage
+func (db *Storage[T]) MatchKey(ctx context.Context, key dynamo.Thing, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	return db.match(key, opts)
+}
+
+// Match applies a pattern matching to elements in the storage
+func (db *Storage[T]) Match(ctx context.Context, key T, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	return db.match(key, opts)
+}
+
+// MatchSeq lazily iterates over all elements matching the pattern
+func (db *Storage[T]) MatchSeq(ctx context.Context, key T, opts ...interface{ MatcherOpt(T) }) iter.Seq2[T, error] {
+	return iterator.Seq(ctx,
+		func(ctx context.Context, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+			return db.Match(ctx, key, opts...)
+		},
+		opts,
+	)
+}
+
+func (db *Storage[T]) match(key dynamo.Thing, opts []interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	q, err := kv.NewQuery[T](key, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var from []byte
+	if q.Cursor != nil {
+		from = encodePrefix(q.HashKey, q.Cursor.SortKey())
+	}
+
+	var (
+		seq  []T
+		next interface{ MatcherOpt(T) }
+	)
+	err = db.service.View(func(tx *bbolt.Tx) (err error) {
+		scan := newScanner(tx.Bucket(db.bucket).Cursor(), encodePrefix(q.HashKey, q.Prefix), from, q.Reverse)
+
+		seq, next, err = q.Eval(func(yield func(curie.IRI, func() (ddbexpr.Item, error)) bool) {
+			for k, v := scan.first(); k != nil; k, v = scan.next() {
+				if !yield(decodeKey(k).sortKey, func() (ddbexpr.Item, error) { return ddbexpr.Unmarshal(v) }) {
+					return
+				}
+			}
+		})
+		return
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return seq, next, nil
+}
+
+// scanner is an ordered range scan over keys with the prefix, it starts
+// after the key (from) if it is defined.
+type scanner struct {
+	*bbolt.Cursor
+	prefix  []byte
+	from    []byte
+	reverse bool
+}
+
+func newScanner(c *bbolt.Cursor, prefix, from []byte, reverse bool) *scanner {
+	return &scanner{Cursor: c, prefix: prefix, from: from, reverse: reverse}
+}
+
+func (s *scanner) first() ([]byte, []byte) {
+	if !s.reverse {
+		if s.from == nil || bytes.Compare(s.from, s.prefix) < 0 {
+			return s.within(s.Seek(s.prefix))
+		}
+
+		k, v := s.Seek(s.from)
+		if bytes.Equal(k, s.from) {
+			k, v = s.Next()
+		}
+		return s.within(k, v)
+	}
+
+	// the upper bound of reverse scan is excluded
+	upper := successor(s.prefix)
+	if s.from != nil && (upper == nil || bytes.Compare(s.from, upper) < 0) {
+		upper = s.from
+	}
+
+	if upper == nil {
+		return s.within(s.Last())
+	}
+
+	if k, _ := s.Seek(upper); k == nil {
+		return s.within(s.Last())
+	}
+	return s.within(s.Prev())
+}
+
+func (s *scanner) next() ([]byte, []byte) {
+	if s.reverse {
+		return s.within(s.Prev())
+	}
+	return s.within(s.Next())
+}
+
+func (s *scanner) within(k, v []byte) ([]byte, []byte) {
+	if k == nil || !bytes.HasPrefix(k, s.prefix) {
+		return nil, nil
+	}
+	return k, v
+}
+
+// successor is the smallest key that does not have the prefix
+func successor(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] != 0xff {
+			key := bytes.Clone(prefix[:i+1])
+			key[i]++
+			return key
+		}
+	}
+	return nil
+}
+
+type cursor struct{ hashKey, sortKey curie.IRI }
+
+func (c cursor) HashKey() curie.IRI { return c.hashKey }
+func (c cursor) SortKey() curie.IRI { return c.sortKey }
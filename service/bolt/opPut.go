@@ -0,0 +1,47 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package bolt
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3/internal/kv"
+	"go.etcd.io/bbolt"
+)
+
+// Put writes entity
+func (db *Storage[T]) Put(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) error {
+	key, err := encodeKey(entity)
+	if err != nil {
+		return err
+	}
+
+	gen, err := kv.Encode(entity)
+	if err != nil {
+		return err
+	}
+
+	return db.service.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(db.bucket)
+
+		item, err := db.get(bucket, key)
+		if err != nil {
+			return err
+		}
+
+		err = kv.CheckCondition(entity, item,
+			map[string]string{}, map[string]types.AttributeValue{}, opts)
+		if err != nil {
+			return err
+		}
+
+		return db.put(bucket, key, gen)
+	})
+}
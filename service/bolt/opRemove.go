@@ -0,0 +1,53 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package bolt
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"github.com/fogfish/dynamo/v3/internal/kv"
+	"go.etcd.io/bbolt"
+)
+
+// Remove discards the entity from the storage, it returns removed entity
+func (db *Storage[T]) Remove(ctx context.Context, key T, opts ...interface{ WriterOpt(T) }) (T, error) {
+	gen, err := encodeKey(key)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	var item ddbexpr.Item
+	err = db.service.Update(func(tx *bbolt.Tx) (err error) {
+		bucket := tx.Bucket(db.bucket)
+
+		item, err = db.get(bucket, gen)
+		if err != nil {
+			return err
+		}
+
+		err = kv.CheckCondition(key, item,
+			map[string]string{}, map[string]types.AttributeValue{}, opts)
+		if err != nil {
+			return err
+		}
+
+		if err := bucket.Delete(gen); err != nil {
+			return errServiceIO.New(err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return db.undefined, err
+	}
+
+	return kv.Decode[T](item)
+}
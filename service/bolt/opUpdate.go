@@ -0,0 +1,90 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package bolt
+
+import (
+	"context"
+
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"github.com/fogfish/dynamo/v3/internal/kv"
+	"github.com/fogfish/dynamo/v3/internal/updateexpr"
+	"github.com/fogfish/dynamo/v3/service/ddb"
+	"go.etcd.io/bbolt"
+)
+
+// Update applies a partial patch to entity and returns new values.
+// Defined attributes of entity overwrite existing ones, the item is
+// created if it does not exist.
+func (db *Storage[T]) Update(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) (T, error) {
+	key, err := encodeKey(entity)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	gen, err := kv.Encode(entity)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	var updated ddbexpr.Item
+	err = db.service.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(db.bucket)
+
+		item, err := db.get(bucket, key)
+		if err != nil {
+			return err
+		}
+
+		updated, err = kv.Update(entity, gen, item, opts)
+		if err != nil {
+			return err
+		}
+
+		return db.put(bucket, key, updated)
+	})
+	if err != nil {
+		return db.undefined, err
+	}
+
+	return kv.Decode[T](updated)
+}
+
+// UpdateWith applies update expression (see ddb.UpdateFor) to the entity.
+// The item is created from the key if it does not exist.
+func (db *Storage[T]) UpdateWith(ctx context.Context, expression ddb.UpdateItemExpression[T], opts ...interface{ WriterOpt(T) }) (T, error) {
+	update := updateexpr.Of(expression)
+	entity := update.Entity.(T)
+
+	key, err := encodeKey(entity)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	var updated ddbexpr.Item
+	err = db.service.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(db.bucket)
+
+		item, err := db.get(bucket, key)
+		if err != nil {
+			return err
+		}
+
+		updated, err = kv.UpdateWith(entity, update, item, opts)
+		if err != nil {
+			return err
+		}
+
+		return db.put(bucket, key, updated)
+	})
+	if err != nil {
+		return db.undefined, err
+	}
+
+	return kv.Decode[T](updated)
+}
@@ -0,0 +1,42 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package bolt
+
+import (
+	"github.com/fogfish/opts"
+	"go.etcd.io/bbolt"
+)
+
+// Option type to configure the storage
+type Option = opts.Option[Options]
+
+// Config Options
+type Options struct {
+	service *bbolt.DB
+	file    string
+}
+
+func (c *Options) checkRequired() error {
+	return opts.Required(c,
+		WithBolt(nil),
+	)
+}
+
+var (
+	// Set bbolt database for the client, the database is shared by storages
+	WithBolt = opts.ForType[Options, *bbolt.DB]()
+
+	// Open bbolt database at the file, the storage owns the database
+	WithFile = opts.ForName[Options, string]("file")
+)
+
+// NewConfig creates Config with default options
+func optsDefault() Options {
+	return Options{}
+}
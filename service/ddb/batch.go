@@ -0,0 +1,267 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+//
+// Internal implementation of batch I/O: chunking, concurrency and retries
+//
+
+const (
+	// Max number of items in BatchWriteItem request
+	batchWriteSize = 25
+
+	// Max number of keys in BatchGetItem request
+	batchGetSize = 100
+
+	// Max backoff delay between retries
+	batchBackoffCap = 5 * time.Second
+)
+
+// split sequence into chunks of given size
+func chunks[A any](seq []A, size int) [][]A {
+	out := make([][]A, 0, (len(seq)+size-1)/size)
+	for len(seq) > size {
+		out = append(out, seq[:size:size])
+		seq = seq[size:]
+	}
+	if len(seq) > 0 {
+		out = append(out, seq)
+	}
+	return out
+}
+
+// run the function over chunks with bounded concurrency
+func (db *Storage[T]) batch(n int, f func(int)) {
+	concurrency := max(db.batchConcurrency, 1)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// upper bound of the delay before the retry. The shift is applied only
+// while it stays below the cap, large attempts overflow the duration otherwise.
+func (db *Storage[T]) backoffCap(attempt int) time.Duration {
+	if db.batchBackoff > batchBackoffCap>>attempt {
+		return batchBackoffCap
+	}
+	return db.batchBackoff << attempt
+}
+
+// sleeps before the retry, using exponential backoff with full jitter.
+// It returns false if retry is not possible.
+func (db *Storage[T]) backoff(ctx context.Context, attempt int) bool {
+	if attempt >= db.batchRetry {
+		return false
+	}
+
+	delay := db.backoffCap(attempt)
+	if delay > 0 {
+		delay = rand.N(delay)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		return false
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// BatchWriteItem does not support conditions, TTL and return values, the
+// options are rejected instead of being ignored.
+func checkBatchOpts[T any](op string, opts []interface{ WriterOpt(T) }) error {
+	for _, opt := range opts {
+		switch opt.(type) {
+		case interface{ ExpiresAt() time.Time }:
+			return errUnsupportedOpt.New(nil, "TTL with "+op)
+		case interface{ ReturnValues() types.ReturnValue }:
+			return errUnsupportedOpt.New(nil, "ReturnValues with "+op)
+		case condition:
+			return errUnsupportedOpt.New(nil, "condition with "+op)
+		default:
+			return errUnsupportedOpt.New(nil, fmt.Sprintf("%T with %s", opt, op))
+		}
+	}
+	return nil
+}
+
+// writes chunk, retries unprocessed items. It returns items, which are
+// not written.
+func (db *Storage[T]) batchWrite(ctx context.Context, seq []types.WriteRequest) ([]types.WriteRequest, error) {
+	for attempt := 0; ; attempt++ {
+		// the chunk is not written if other one has failed
+		if err := ctx.Err(); err != nil {
+			return seq, errServiceIO.New(err)
+		}
+
+		req := &dynamodb.BatchWriteItemInput{
+			RequestItems: map[string][]types.WriteRequest{
+				db.table: seq,
+			},
+		}
+
+		val, err := db.service.BatchWriteItem(ctx, req)
+		if err != nil {
+			return seq, errServiceIO.New(errService(err))
+		}
+
+		seq = val.UnprocessedItems[db.table]
+		if len(seq) == 0 {
+			return nil, nil
+		}
+
+		if !db.backoff(ctx, attempt) {
+			return seq, errPartialBatch(ctx, len(seq))
+		}
+	}
+}
+
+// writes all items, it returns items, which are not written.
+func (db *Storage[T]) batchWriteAll(ctx context.Context, seq []types.WriteRequest) ([]types.WriteRequest, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once    sync.Once
+		failure error
+	)
+
+	chunk := chunks(seq, batchWriteSize)
+	fails := make([][]types.WriteRequest, len(chunk))
+
+	db.batch(len(chunk), func(i int) {
+		var err error
+		fails[i], err = db.batchWrite(ctx, chunk[i])
+		if err != nil {
+			// the first failure cancels other chunks
+			once.Do(func() {
+				failure = err
+				cancel()
+			})
+		}
+	})
+
+	var failed []types.WriteRequest
+	for i := range chunk {
+		failed = append(failed, fails[i]...)
+	}
+
+	return failed, failure
+}
+
+// decodes items of write requests
+func (db *Storage[T]) decodeWriteRequests(seq []types.WriteRequest) []T {
+	items := make([]T, len(seq))
+	for i, r := range seq {
+		switch {
+		case r.PutRequest != nil:
+			items[i], _ = db.codec.Decode(r.PutRequest.Item)
+		case r.DeleteRequest != nil:
+			items[i], _ = db.codec.Decode(r.DeleteRequest.Key)
+		}
+	}
+	return items
+}
+
+// reads chunk, retries unprocessed keys. It returns items, which are read
+// before the failure, together with the error.
+func (db *Storage[T]) batchGet(ctx context.Context, keys []map[string]types.AttributeValue, consistentRead *bool) ([]map[string]types.AttributeValue, error) {
+	var items []map[string]types.AttributeValue
+
+	for attempt := 0; ; attempt++ {
+		req := &dynamodb.BatchGetItemInput{
+			RequestItems: map[string]types.KeysAndAttributes{
+				db.table: {
+					Keys:                     keys,
+					ProjectionExpression:     db.schema.Projection,
+					ExpressionAttributeNames: db.schema.ExpectedAttributeNames,
+					ConsistentRead:           consistentRead,
+				},
+			},
+		}
+
+		val, err := db.service.BatchGetItem(ctx, req)
+		if err != nil {
+			return items, errServiceIO.New(errService(err))
+		}
+
+		items = append(items, val.Responses[db.table]...)
+
+		unprocessed, has := val.UnprocessedKeys[db.table]
+		if !has || len(unprocessed.Keys) == 0 {
+			return items, nil
+		}
+		keys = unprocessed.Keys
+
+		if !db.backoff(ctx, attempt) {
+			return items, errPartialBatch(ctx, len(keys))
+		}
+	}
+}
+
+// reads all keys, it returns items, which are read, together with the error.
+func (db *Storage[T]) batchGetAll(ctx context.Context, keys []map[string]types.AttributeValue, consistentRead *bool) ([]map[string]types.AttributeValue, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once    sync.Once
+		failure error
+	)
+
+	chunk := chunks(keys, batchGetSize)
+	items := make([][]map[string]types.AttributeValue, len(chunk))
+
+	db.batch(len(chunk), func(i int) {
+		var err error
+		items[i], err = db.batchGet(ctx, chunk[i], consistentRead)
+		if err != nil {
+			// the first failure cancels other chunks
+			once.Do(func() {
+				failure = err
+				cancel()
+			})
+		}
+	})
+
+	seq := make([]map[string]types.AttributeValue, 0, len(keys))
+	for i := range chunk {
+		seq = append(seq, items[i]...)
+	}
+
+	return seq, failure
+}
@@ -0,0 +1,211 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/it/v2"
+)
+
+// batcher processes only first `capacity` items of each request
+type batcher struct {
+	DynamoDB
+	sync.Mutex
+	capacity   int
+	largest    int
+	requests   int
+	written    map[string]bool
+	consistent bool
+}
+
+func (mock *batcher) BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	mock.Lock()
+	defer mock.Unlock()
+
+	seq := input.RequestItems["test"]
+	mock.largest = max(mock.largest, len(seq))
+	mock.requests++
+
+	n := min(mock.capacity, len(seq))
+	for _, r := range seq[:n] {
+		mock.written[r.PutRequest.Item["suffix"].(*types.AttributeValueMemberS).Value] = true
+	}
+
+	out := &dynamodb.BatchWriteItemOutput{}
+	if n < len(seq) {
+		out.UnprocessedItems = map[string][]types.WriteRequest{"test": seq[n:]}
+	}
+	return out, nil
+}
+
+func (mock *batcher) BatchGetItem(ctx context.Context, input *dynamodb.BatchGetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	mock.Lock()
+	defer mock.Unlock()
+
+	keys := input.RequestItems["test"].Keys
+	mock.consistent = aws.ToBool(input.RequestItems["test"].ConsistentRead)
+	mock.largest = max(mock.largest, len(keys))
+
+	n := min(mock.capacity, len(keys))
+	out := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]types.AttributeValue{"test": keys[:n]},
+	}
+	if n < len(keys) {
+		out.UnprocessedKeys = map[string]types.KeysAndAttributes{"test": {Keys: keys[n:]}}
+	}
+	return out, nil
+}
+
+func batchOf(n int) []tKeyCondition {
+	seq := make([]tKeyCondition, n)
+	for i := 0; i < n; i++ {
+		seq[i] = tKeyCondition{Prefix: "a", Suffix: curie.IRI(strconv.Itoa(i))}
+	}
+	return seq
+}
+
+func TestBatch(t *testing.T) {
+	t.Run("Chunks", func(t *testing.T) {
+		it.Then(t).Should(
+			it.Equal(len(chunks(batchOf(0), 25)), 0),
+			it.Equal(len(chunks(batchOf(25), 25)), 1),
+			it.Equal(len(chunks(batchOf(26), 25)), 2),
+			it.Equal(len(chunks(batchOf(26), 25)[1]), 1),
+		)
+	})
+
+	t.Run("BatchPut", func(t *testing.T) {
+		mock := &batcher{capacity: 10, written: map[string]bool{}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock), WithBatchBackoff(time.Millisecond)))
+
+		out, err := db.BatchPut(context.Background(), batchOf(60))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Seq(out).BeEmpty(),
+			it.Equal(len(mock.written), 60),
+			it.Equal(mock.largest, batchWriteSize),
+		)
+	})
+
+	t.Run("BatchPutDeadline", func(t *testing.T) {
+		mock := &batcher{capacity: 0, written: map[string]bool{}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock), WithBatchBackoff(time.Millisecond)))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		out, err := db.BatchPut(ctx, batchOf(30))
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		).Should(
+			it.Equal(len(out), 30),
+		)
+	})
+
+	t.Run("BatchPutRetriesExhausted", func(t *testing.T) {
+		mock := &batcher{capacity: 0, written: map[string]bool{}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock),
+			WithBatchConcurrency(1), WithBatchRetry(1), WithBatchBackoff(time.Millisecond),
+		))
+
+		out, err := db.BatchPut(context.Background(), batchOf(60))
+
+		var e *dynamo.ServiceError
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+			it.True(errors.Is(err, context.Canceled)),
+		).Should(
+			it.True(errors.As(err, &e)),
+			it.True(e.Kind == dynamo.ErrPartialBatch),
+			it.True(e.Err != nil),
+			// the failed chunk cancels remaining ones
+			it.Equal(mock.requests, 2),
+			it.Equal(len(out), 60),
+		)
+	})
+
+	t.Run("BatchPutUnsupported", func(t *testing.T) {
+		mock := &batcher{capacity: 10, written: map[string]bool{}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		_, err := db.BatchPut(context.Background(), batchOf(10), dynamo.TTL[tKeyCondition](time.Hour))
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		).Should(
+			it.Equal(mock.requests, 0),
+		)
+
+		_, err = db.BatchRemove(context.Background(), batchOf(10), ClauseFor[tKeyCondition, curie.IRI]("Suffix").Exists())
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		).Should(
+			it.Equal(mock.requests, 0),
+		)
+	})
+
+	t.Run("BatchGet", func(t *testing.T) {
+		mock := &batcher{capacity: 40}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock), WithBatchBackoff(time.Millisecond)))
+
+		seq, err := db.BatchGet(context.Background(), batchOf(250))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 250),
+			it.Equal(mock.largest, batchGetSize),
+		)
+	})
+
+	t.Run("BatchGetDeadline", func(t *testing.T) {
+		mock := &batcher{capacity: 10}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock), WithBatchRetry(1), WithBatchBackoff(time.Millisecond)))
+
+		seq, err := db.BatchGet(context.Background(), batchOf(30))
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		).Should(
+			it.True(errors.Is(err, dynamo.ErrPartialBatch)),
+			it.Equal(len(seq), 20),
+		)
+	})
+
+	t.Run("Backoff", func(t *testing.T) {
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(&batcher{}), WithBatchBackoff(time.Millisecond)))
+
+		it.Then(t).Should(
+			it.Equal(db.backoffCap(0), time.Millisecond),
+			it.Equal(db.backoffCap(3), 8*time.Millisecond),
+			it.Equal(db.backoffCap(13), batchBackoffCap),
+			it.Equal(db.backoffCap(44), batchBackoffCap),
+			it.Equal(db.backoffCap(100), batchBackoffCap),
+		)
+	})
+
+	t.Run("BatchGetConsistentRead", func(t *testing.T) {
+		mock := &batcher{capacity: 40}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock), WithBatchBackoff(time.Millisecond)))
+
+		seq, err := db.BatchGet(context.Background(), batchOf(50), dynamo.ConsistentRead[tKeyCondition]())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 50),
+			it.True(mock.consistent),
+		)
+	})
+}
@@ -0,0 +1,311 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file implements evaluation of condition nodes against the item
+// returned by DynamoDB with ConditionalCheckFailedException. It is used
+// only to find the failed clause, the condition is evaluated by DynamoDB.
+//
+
+package ddb
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// outcome of the condition: the result is meaningful only if it is known
+type outcome struct{ ok, known bool }
+
+var unknown = outcome{}
+
+func known(ok bool) outcome { return outcome{ok: ok, known: true} }
+
+func (op dyadicCondition[T, A]) eval(item map[string]types.AttributeValue) outcome {
+	val, has := item[op.key]
+	if !has {
+		// the value of missing attribute is not comparable
+		if op.op == "<>" {
+			return unknown
+		}
+		return known(false)
+	}
+
+	lit, err := attributevalue.Marshal(op.val)
+	if err != nil {
+		return unknown
+	}
+
+	switch op.op {
+	case "=":
+		return equalValue(val, lit)
+	case "<>":
+		eq := equalValue(val, lit)
+		return outcome{ok: !eq.ok, known: eq.known}
+	}
+
+	c, ok := compareValue(val, lit)
+	if !ok {
+		return unknown
+	}
+
+	switch op.op {
+	case "<":
+		return known(c < 0)
+	case "<=":
+		return known(c <= 0)
+	case ">":
+		return known(c > 0)
+	case ">=":
+		return known(c >= 0)
+	}
+
+	return unknown
+}
+
+func (op unaryCondition[T]) eval(item map[string]types.AttributeValue) outcome {
+	_, has := item[op.key]
+	if op.op == "attribute_not_exists" {
+		return known(!has)
+	}
+	return known(has)
+}
+
+func (op betweenCondition[T, A]) eval(item map[string]types.AttributeValue) outcome {
+	val, has := item[op.key]
+	if !has {
+		return known(false)
+	}
+
+	litA, errA := attributevalue.Marshal(op.a)
+	litB, errB := attributevalue.Marshal(op.b)
+	if errA != nil || errB != nil {
+		return unknown
+	}
+
+	a, okA := compareValue(val, litA)
+	b, okB := compareValue(val, litB)
+	if !okA || !okB {
+		return unknown
+	}
+
+	return known(a >= 0 && b <= 0)
+}
+
+func (op inCondition[T, A]) eval(item map[string]types.AttributeValue) outcome {
+	val, has := item[op.key]
+	if !has {
+		return known(false)
+	}
+
+	for _, x := range op.seq {
+		lit, err := attributevalue.Marshal(x)
+		if err != nil {
+			return unknown
+		}
+
+		eq := equalValue(val, lit)
+		if !eq.known {
+			return unknown
+		}
+		if eq.ok {
+			return known(true)
+		}
+	}
+
+	return known(false)
+}
+
+func (op functionalCondition[T, A]) eval(item map[string]types.AttributeValue) outcome {
+	val, has := item[op.key]
+	if !has {
+		return known(false)
+	}
+
+	lit, err := attributevalue.Marshal(op.val)
+	if err != nil {
+		return unknown
+	}
+
+	switch op.fun {
+	case "begins_with":
+		return beginsWith(val, lit)
+	case "contains":
+		return contains(val, lit)
+	}
+
+	return unknown
+}
+
+// three-valued logic of joined conditions, the result is unknown if any
+// of nodes is unknown and the rest does not decide it
+func (op join[T]) eval(item map[string]types.AttributeValue) outcome {
+	or := op.op == " or "
+	result := known(!or)
+
+	for _, c := range conditionsOf(op.seq) {
+		x := c.eval(item)
+		switch {
+		case x.known && x.ok == or:
+			return known(or)
+		case !x.known:
+			result = unknown
+		}
+	}
+
+	return result
+}
+
+// compares scalar values, only numbers, strings and binaries are ordered
+func compareValue(a, b types.AttributeValue) (int, bool) {
+	switch a := a.(type) {
+	case *types.AttributeValueMemberN:
+		if b, ok := b.(*types.AttributeValueMemberN); ok {
+			return compareNumber(a.Value, b.Value)
+		}
+	case *types.AttributeValueMemberS:
+		if b, ok := b.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(a.Value, b.Value), true
+		}
+	case *types.AttributeValueMemberB:
+		if b, ok := b.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(a.Value, b.Value), true
+		}
+	}
+
+	return 0, false
+}
+
+// numbers are decimals of arbitrary precision
+func compareNumber(a, b string) (int, bool) {
+	x, okX := new(big.Rat).SetString(a)
+	y, okY := new(big.Rat).SetString(b)
+	if !okX || !okY {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+// values of different types are never equal, lists and maps are not supported
+func equalValue(a, b types.AttributeValue) outcome {
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return known(false)
+	}
+
+	if c, ok := compareValue(a, b); ok {
+		return known(c == 0)
+	}
+
+	switch a := a.(type) {
+	case *types.AttributeValueMemberBOOL:
+		return known(a.Value == b.(*types.AttributeValueMemberBOOL).Value)
+	case *types.AttributeValueMemberNULL:
+		return known(true)
+	case *types.AttributeValueMemberSS:
+		return equalSet(a.Value, b.(*types.AttributeValueMemberSS).Value, func(x, y string) bool { return x == y })
+	case *types.AttributeValueMemberNS:
+		return equalSet(a.Value, b.(*types.AttributeValueMemberNS).Value, func(x, y string) bool {
+			c, ok := compareNumber(x, y)
+			return ok && c == 0
+		})
+	case *types.AttributeValueMemberBS:
+		return equalSet(a.Value, b.(*types.AttributeValueMemberBS).Value, bytes.Equal)
+	}
+
+	return unknown
+}
+
+func equalSet[A any](a, b []A, eq func(A, A) bool) outcome {
+	return known(len(a) == len(b) && subset(a, b, eq))
+}
+
+func subset[A any](a, b []A, eq func(A, A) bool) bool {
+	for _, x := range a {
+		if !member(x, b, eq) {
+			return false
+		}
+	}
+	return true
+}
+
+func member[A any](x A, seq []A, eq func(A, A) bool) bool {
+	for _, y := range seq {
+		if eq(x, y) {
+			return true
+		}
+	}
+	return false
+}
+
+func beginsWith(val, lit types.AttributeValue) outcome {
+	switch val := val.(type) {
+	case *types.AttributeValueMemberS:
+		if lit, ok := lit.(*types.AttributeValueMemberS); ok {
+			return known(strings.HasPrefix(val.Value, lit.Value))
+		}
+		return known(false)
+	case *types.AttributeValueMemberB:
+		if lit, ok := lit.(*types.AttributeValueMemberB); ok {
+			return known(bytes.HasPrefix(val.Value, lit.Value))
+		}
+		return known(false)
+	}
+
+	return known(false)
+}
+
+func contains(val, lit types.AttributeValue) outcome {
+	switch val := val.(type) {
+	case *types.AttributeValueMemberS:
+		if lit, ok := lit.(*types.AttributeValueMemberS); ok {
+			return known(strings.Contains(val.Value, lit.Value))
+		}
+		return known(false)
+	case *types.AttributeValueMemberB:
+		if lit, ok := lit.(*types.AttributeValueMemberB); ok {
+			return known(bytes.Contains(val.Value, lit.Value))
+		}
+		return known(false)
+	case *types.AttributeValueMemberSS:
+		if lit, ok := lit.(*types.AttributeValueMemberS); ok {
+			return known(member(lit.Value, val.Value, func(x, y string) bool { return x == y }))
+		}
+		return known(false)
+	case *types.AttributeValueMemberNS:
+		if lit, ok := lit.(*types.AttributeValueMemberN); ok {
+			return known(member(lit.Value, val.Value, func(x, y string) bool {
+				c, ok := compareNumber(x, y)
+				return ok && c == 0
+			}))
+		}
+		return known(false)
+	case *types.AttributeValueMemberBS:
+		if lit, ok := lit.(*types.AttributeValueMemberB); ok {
+			return known(member(lit.Value, val.Value, bytes.Equal))
+		}
+		return known(false)
+	case *types.AttributeValueMemberL:
+		for _, x := range val.Value {
+			eq := equalValue(x, lit)
+			if !eq.known {
+				return unknown
+			}
+			if eq.ok {
+				return known(true)
+			}
+		}
+		return known(false)
+	}
+
+	return known(false)
+}
@@ -0,0 +1,83 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/it/v2"
+)
+
+type tEval struct {
+	Prefix curie.IRI `dynamodbav:"prefix,omitempty"`
+	Name   string    `dynamodbav:"name,omitempty"`
+	Age    int       `dynamodbav:"age,omitempty"`
+	Tags   []string  `dynamodbav:"tags,omitempty,stringset"`
+	Refs   []string  `dynamodbav:"refs,omitempty"`
+}
+
+func (x tEval) HashKey() curie.IRI { return x.Prefix }
+func (x tEval) SortKey() curie.IRI { return "" }
+
+func TestConditionEval(t *testing.T) {
+	var (
+		name = ClauseFor[tEval, string]("Name")
+		age  = ClauseFor[tEval, int]("Age")
+		tags = ClauseFor[tEval, string]("Tags")
+		refs = ClauseFor[tEval, string]("Refs")
+	)
+
+	item := map[string]types.AttributeValue{
+		"prefix": &types.AttributeValueMemberS{Value: "a"},
+		"name":   &types.AttributeValueMemberS{Value: "Joe"},
+		"age":    &types.AttributeValueMemberN{Value: "10"},
+		"tags":   &types.AttributeValueMemberSS{Value: []string{"x", "y"}},
+	}
+
+	eval := func(opt interface{ WriterOpt(tEval) }) outcome {
+		return opt.(condition).eval(item)
+	}
+
+	it.Then(t).Should(
+		// numbers are compared as decimals, not as strings
+		it.Equal(eval(age.Gt(9)), known(true)),
+		it.Equal(eval(age.Eq(10)), known(true)),
+		it.Equal(eval(age.Between(1, 9)), known(false)),
+		it.Equal(eval(age.In(1, 10)), known(true)),
+		it.Equal(eval(name.Eq("Jim")), known(false)),
+		it.Equal(eval(name.Ne("Jim")), known(true)),
+		it.Equal(eval(name.HasPrefix("Jo")), known(true)),
+		it.Equal(eval(name.NotExists()), known(false)),
+		it.Equal(eval(tags.Contains("y")), known(true)),
+		it.Equal(eval(tags.Contains("z")), known(false)),
+
+		// missing attributes
+		it.Equal(eval(refs.Ne("x")), unknown),
+		it.Equal(eval(refs.NotExists()), known(true)),
+		it.Equal(eval(refs.Contains("x")), known(false)),
+
+		// joins use three-valued logic
+		it.Equal(eval(OneOf(name.NotExists(), name.Eq("Joe"))), known(true)),
+		it.Equal(eval(AllOf(name.Eq("Joe"), age.Lt(1))), known(false)),
+		it.Equal(eval(OneOf(refs.Ne("x"), name.Eq("Jim"))), unknown),
+		it.Equal(eval(OneOf(refs.Ne("x"), name.Eq("Joe"))), known(true)),
+		it.Equal(eval(AllOf(refs.Ne("x"), name.Eq("Jim"))), known(false)),
+
+		// values of different types are never equal
+		it.Equal(eval(ClauseFor[tEval, int]("Name").Eq(10)), known(false)),
+	)
+
+	delete(item, "age")
+	it.Then(t).Should(
+		it.Equal(eval(age.Gt(9)), known(false)),
+		it.Equal(eval(age.Ne(9)), unknown),
+	)
+}
@@ -82,6 +82,32 @@ func newConditionExpression[T dynamo.Thing, A any](t hseq.Type[T]) ConditionExpr
 // Internal implementation of Constrain effects for storage
 // type Constraints[T dynamo.Thing, A any] struct{ key string }
 
+// condition is a node of condition expression, each node declares the
+// semantic of its failure so that precondition failed error is classified
+// without parsing the expression.
+type condition interface {
+	Apply(map[string]string, map[string]types.AttributeValue) string
+	String() string
+	failure() failure
+	eval(map[string]types.AttributeValue) outcome
+}
+
+// failure semantic of the condition: either the item exists but conflicts
+// with the condition or the item is gone.
+type failure struct{ conflict, gone bool }
+
+// uniqueLet allocates placeholder of the value, clauses on the same
+// attribute (e.g. range of values) get distinct placeholders
+func uniqueLet(expressionAttributeValues map[string]types.AttributeValue, prefix string) string {
+	let := prefix + "__"
+	for i := 1; ; i++ {
+		if _, has := expressionAttributeValues[let]; !has {
+			return let
+		}
+		let = prefix + "_" + strconv.Itoa(i) + "__"
+	}
+}
+
 // Eq is equal condition
 //
 //	name.Eq(x) ⟼ Field = :value
@@ -147,7 +173,7 @@ func (op dyadicCondition[T, A]) Apply(
 	}
 
 	key := "#__c_" + op.key + "__"
-	let := ":__c_" + op.key + "__"
+	let := uniqueLet(expressionAttributeValues, ":__c_"+op.key)
 	expressionAttributeValues[let] = lit
 	expressionAttributeNames[key] = op.key
 	expr := "(" + key + " " + op.op + " " + let + ")"
@@ -155,6 +181,13 @@ func (op dyadicCondition[T, A]) Apply(
 	return expr
 }
 
+func (op dyadicCondition[T, A]) String() string {
+	return fmt.Sprintf("%s %s %v", op.key, op.op, op.val)
+}
+
+// value of existing attribute does not satisfy the condition
+func (op dyadicCondition[T, A]) failure() failure { return failure{conflict: true} }
+
 // Exists attribute constrain
 //
 //	name.Exists(x) ⟼ attribute_exists(name)
@@ -192,6 +225,16 @@ func (op unaryCondition[T]) Apply(
 	return expr
 }
 
+func (op unaryCondition[T]) String() string { return op.op + "(" + op.key + ")" }
+
+// attribute_not_exists fails on existing attribute, attribute_exists on missing
+func (op unaryCondition[T]) failure() failure {
+	if op.op == "attribute_not_exists" {
+		return failure{conflict: true}
+	}
+	return failure{gone: true}
+}
+
 // Is matches either Eq or NotExists if value is not defined
 func (ce ConditionExpression[T, A]) Is(val string) interface{ WriterOpt(T) } {
 	if val == "_" {
@@ -235,9 +278,9 @@ func (op betweenCondition[T, A]) Apply(
 	}
 
 	key := "#__c_" + op.key + "__"
-	letA := ":__c_" + op.key + "_a__"
-	letB := ":__c_" + op.key + "_b__"
+	letA := uniqueLet(expressionAttributeValues, ":__c_"+op.key+"_a")
 	expressionAttributeValues[letA] = litA
+	letB := uniqueLet(expressionAttributeValues, ":__c_"+op.key+"_b")
 	expressionAttributeValues[letB] = litB
 	expressionAttributeNames[key] = op.key
 	expr := "(" + key + " BETWEEN " + letA + " AND " + letB + ")"
@@ -245,6 +288,12 @@ func (op betweenCondition[T, A]) Apply(
 	return expr
 }
 
+func (op betweenCondition[T, A]) String() string {
+	return fmt.Sprintf("%s BETWEEN %v AND %v", op.key, op.a, op.b)
+}
+
+func (op betweenCondition[T, A]) failure() failure { return failure{conflict: true} }
+
 // In attribute condition
 //
 //	name.Between(a, b, c) ⟼ Field IN (:a, :b, :c)
@@ -279,7 +328,7 @@ func (op inCondition[T, A]) Apply(
 			return ""
 		}
 		lits[i] = lit
-		lets[i] = ":__c_" + op.key + "_" + strconv.Itoa(i) + "__"
+		lets[i] = uniqueLet(expressionAttributeValues, ":__c_"+op.key+"_"+strconv.Itoa(i))
 		expressionAttributeValues[lets[i]] = lits[i]
 	}
 
@@ -288,6 +337,16 @@ func (op inCondition[T, A]) Apply(
 	return expr
 }
 
+func (op inCondition[T, A]) String() string {
+	seq := make([]string, len(op.seq))
+	for i, x := range op.seq {
+		seq[i] = fmt.Sprint(x)
+	}
+	return op.key + " IN (" + strings.Join(seq, ", ") + ")"
+}
+
+func (op inCondition[T, A]) failure() failure { return failure{conflict: true} }
+
 // HasPrefix attribute condition
 //
 // name.HasPrefix(x) ⟼ begins_with(Field, :value)
@@ -325,7 +384,7 @@ func (op functionalCondition[T, A]) Apply(
 	}
 
 	key := "#__c_" + op.key + "__"
-	let := ":__c_" + op.key + "__"
+	let := uniqueLet(expressionAttributeValues, ":__c_"+op.key)
 	expressionAttributeValues[let] = lit
 	expressionAttributeNames[key] = op.key
 	expr := "(" + op.fun + "(" + key + "," + let + "))"
@@ -333,6 +392,12 @@ func (op functionalCondition[T, A]) Apply(
 	return expr
 }
 
+func (op functionalCondition[T, A]) String() string {
+	return fmt.Sprintf("%s(%s, %v)", op.fun, op.key, op.val)
+}
+
+func (op functionalCondition[T, A]) failure() failure { return failure{conflict: true} }
+
 // Optimistic defines optimistic concurrency control (aka optimistic lock) condition.
 //
 //	name.Optimistic(x) ⟼ (Field = :value) or (attribute_not_exists(name))
@@ -375,6 +440,106 @@ func (op join[T]) Apply(
 	return strings.Join(expr, op.op)
 }
 
+func (op join[T]) String() string {
+	seq := make([]string, 0, len(op.seq))
+	for _, opt := range op.seq {
+		if c, ok := opt.(condition); ok {
+			seq = append(seq, "("+c.String()+")")
+		}
+	}
+	return strings.Join(seq, op.op)
+}
+
+// OneOf fails when all conditions fail, semantic of each one holds.
+// AllOf fails when any of conditions fails, semantic is defined only
+// if all conditions agree on it.
+func (op join[T]) failure() failure {
+	seq := conditionsOf(op.seq)
+	if op.op == " or " {
+		return anyOf(seq)
+	}
+	return allOf(seq)
+}
+
+func anyOf(seq []condition) failure {
+	var f failure
+	for _, c := range seq {
+		x := c.failure()
+		f.conflict = f.conflict || x.conflict
+		f.gone = f.gone || x.gone
+	}
+	return f
+}
+
+func allOf(seq []condition) failure {
+	if len(seq) == 0 {
+		return failure{}
+	}
+
+	f := failure{conflict: true, gone: true}
+	for _, c := range seq {
+		x := c.failure()
+		f.conflict = f.conflict && x.conflict
+		f.gone = f.gone && x.gone
+	}
+	return f
+}
+
+// conditions defined by options
+func conditionsOf[T any](opts []interface{ WriterOpt(T) }) []condition {
+	seq := make([]condition, 0, len(opts))
+	for _, opt := range opts {
+		if c, ok := opt.(condition); ok {
+			seq = append(seq, c)
+		}
+	}
+	return seq
+}
+
+// Filter joins multiple constraint into filter expression of Match, items
+// that do not satisfy constraints are dropped by DynamoDB before they are
+// returned (aka AND logical expression).
+//
+//	db.Match(ctx, key, ddb.Filter(name.Eq("Joe Doe"), age.Gt(18)))
+func Filter[T any](seq ...interface{ WriterOpt(T) }) interface{ MatcherOpt(T) } {
+	return &filter[T]{seq: seq}
+}
+
+type filter[T any] struct {
+	seq []interface{ WriterOpt(T) }
+}
+
+func (op filter[T]) MatcherOpt(T) {}
+
+func (op filter[T]) FilterExpression(
+	expressionAttributeNames map[string]string,
+	expressionAttributeValues map[string]types.AttributeValue,
+) string {
+	expr := make([]string, 0, len(op.seq))
+	for _, opt := range op.seq {
+		if ap, ok := opt.(interface {
+			Apply(map[string]string, map[string]types.AttributeValue) string
+		}); ok {
+			term := ap.Apply(expressionAttributeNames, expressionAttributeValues)
+			switch {
+			case term == "":
+				continue
+			case isJoin[T](opt):
+				// logical combinators have no outer brackets
+				term = "(" + term + ")"
+			}
+			expr = append(expr, term)
+		}
+	}
+
+	return strings.Join(expr, " and ")
+}
+
+func isJoin[T any](opt interface{ WriterOpt(T) }) bool {
+	_, ok := opt.(*join[T])
+	return ok
+}
+
 // Internal implementation of conditional expressions for dynamo db
 func maybeConditionExpression[T dynamo.Thing](
 	conditionExpression **string,
@@ -387,12 +552,12 @@ func maybeConditionExpression[T dynamo.Thing](
 		expressionAttributeNames = map[string]string{}
 		expressionAttributeValues = map[string]types.AttributeValue{}
 
-		seq := make([]string, len(opts))
-		for i, opt := range opts {
+		seq := make([]string, 0, len(opts))
+		for _, opt := range opts {
 			if ap, ok := opt.(interface {
 				Apply(map[string]string, map[string]types.AttributeValue) string
 			}); ok {
-				seq[i] = ap.Apply(expressionAttributeNames, expressionAttributeValues)
+				seq = append(seq, ap.Apply(expressionAttributeNames, expressionAttributeValues))
 			}
 		}
 
@@ -422,12 +587,12 @@ func maybeUpdateConditionExpression[T dynamo.Thing](
 	opts []interface{ WriterOpt(T) },
 ) {
 
-	seq := make([]string, len(opts))
-	for i, opt := range opts {
+	seq := make([]string, 0, len(opts))
+	for _, opt := range opts {
 		if ap, ok := opt.(interface {
 			Apply(map[string]string, map[string]types.AttributeValue) string
 		}); ok {
-			seq[i] = ap.Apply(expressionAttributeNames, expressionAttributeValues)
+			seq = append(seq, ap.Apply(expressionAttributeNames, expressionAttributeValues))
 		}
 	}
 
@@ -10,15 +10,20 @@ package ddb_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
 	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
 	"github.com/fogfish/dynamo/v3/internal/ddbtest"
 	"github.com/fogfish/dynamo/v3/internal/dynamotest"
 	"github.com/fogfish/dynamo/v3/service/ddb"
+	"github.com/fogfish/dynamo/v3/service/ddb/ddbfake"
 	"github.com/fogfish/it/v2"
 )
 
@@ -132,6 +137,160 @@ func TestDdbUpdateWithConstrain(t *testing.T) {
 	)
 }
 
+func TestDdbConditionFailure(t *testing.T) {
+	type failure interface {
+		Conflict() bool
+		Gone() bool
+		Clause() string
+	}
+
+	name := ddb.ClauseFor[person, string]("Name")
+	age := ddb.ClauseFor[person, int]("Age")
+	db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(ddbfake.New())))
+	if err := db.Put(context.TODO(), entityStruct()); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("NotExists", func(t *testing.T) {
+		err := db.Put(context.TODO(), entityStruct(), name.NotExists())
+		f, ok := err.(failure)
+		it.Then(t).Should(
+			it.True(ok),
+			it.True(f.Conflict()),
+			it.True(!f.Gone()),
+			it.Equal(f.Clause(), "attribute_not_exists(name)"),
+		)
+	})
+
+	t.Run("Exists", func(t *testing.T) {
+		_, err := db.Remove(context.TODO(), person{Prefix: "dead:beef", Suffix: "2"}, name.Exists())
+		f, ok := err.(failure)
+		it.Then(t).Should(
+			it.True(ok),
+			it.True(!f.Conflict()),
+			it.True(f.Gone()),
+		)
+	})
+
+	t.Run("Ne", func(t *testing.T) {
+		_, err := db.Remove(context.TODO(), entityStruct(), name.Ne(entityStruct().Name))
+		f, ok := err.(failure)
+		it.Then(t).Should(
+			it.True(ok),
+			it.True(f.Conflict()),
+			it.True(!f.Gone()),
+		)
+	})
+
+	t.Run("Le", func(t *testing.T) {
+		_, err := db.Update(context.TODO(), entityStruct(), age.Le(1))
+		f, ok := err.(failure)
+		it.Then(t).Should(
+			it.True(ok),
+			it.True(f.Conflict()),
+			it.Equal(f.Clause(), "age <= 1"),
+		)
+	})
+
+	t.Run("OneOf", func(t *testing.T) {
+		_, err := db.Update(context.TODO(), entityStruct(), name.Optimistic("Jim"))
+		f, ok := err.(failure)
+		it.Then(t).Should(
+			it.True(ok),
+			it.True(f.Conflict()),
+			it.True(!f.Gone()),
+			it.Equal(f.Clause(), "(attribute_not_exists(name)) or (name = Jim)"),
+		)
+	})
+
+	t.Run("FailedClause", func(t *testing.T) {
+		_, err := db.Update(context.TODO(), entityStruct(),
+			age.Eq(entityStruct().Age),
+			name.Eq("Jim"),
+			ddb.ReturnValuesOnConditionCheckFailure[person](),
+		)
+		f, ok := err.(failure)
+		it.Then(t).Should(
+			it.True(ok),
+			it.True(f.Conflict()),
+			it.Equal(f.Clause(), "name = Jim"),
+		)
+	})
+
+	t.Run("WithoutCondition", func(t *testing.T) {
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(&failingDynamoDB{err: &types.ConditionalCheckFailedException{}})))
+
+		err := db.Put(context.TODO(), entityStruct())
+		f, ok := err.(failure)
+		it.Then(t).Should(
+			it.True(ok),
+			it.True(!f.Conflict()),
+			it.True(!f.Gone()),
+			it.Equal(f.Clause(), ""),
+		)
+	})
+}
+
+func TestDdbErrors(t *testing.T) {
+	t.Run("NotFound", func(t *testing.T) {
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(ddbfake.New())))
+
+		_, err := db.Get(context.TODO(), entityStruct())
+		it.Then(t).Should(it.True(errors.Is(err, dynamo.ErrNotFound)))
+	})
+
+	t.Run("PreConditionFailed", func(t *testing.T) {
+		name := ddb.ClauseFor[person, string]("Name")
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(ddbfake.New())))
+
+		_, err := db.Remove(context.TODO(), entityStruct(), name.Exists())
+		it.Then(t).Should(
+			it.True(errors.Is(err, dynamo.ErrPreConditionFailed)),
+			it.True(errors.Is(err, dynamo.ErrGone)),
+			it.True(!errors.Is(err, dynamo.ErrConflict)),
+		)
+	})
+
+	for code, kind := range map[string]error{
+		"ProvisionedThroughputExceededException": dynamo.ErrThrottled,
+		"ThrottlingException":                    dynamo.ErrThrottled,
+		"ValidationException":                    dynamo.ErrValidation,
+	} {
+		t.Run(code, func(t *testing.T) {
+			fail := &smithy.GenericAPIError{Code: code, Message: "failed"}
+			db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(&failingDynamoDB{err: fail})))
+
+			var e *smithy.GenericAPIError
+			err := db.Put(context.TODO(), entityStruct())
+			it.Then(t).Should(
+				it.True(errors.Is(err, kind)),
+				it.True(errors.As(err, &e)),
+			)
+		})
+	}
+
+	t.Run("ItemTooLarge", func(t *testing.T) {
+		fail := &smithy.GenericAPIError{Code: "ValidationException", Message: "Item size has exceeded the maximum allowed size"}
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(&failingDynamoDB{err: fail})))
+
+		err := db.Put(context.TODO(), entityStruct())
+		it.Then(t).Should(
+			it.True(errors.Is(err, dynamo.ErrItemTooLarge)),
+			it.True(!errors.Is(err, dynamo.ErrValidation)),
+		)
+	})
+}
+
+// failingDynamoDB fails every write with the error
+type failingDynamoDB struct {
+	ddb.DynamoDB
+	err error
+}
+
+func (mock *failingDynamoDB) PutItem(context.Context, *dynamodb.PutItemInput, ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	return nil, mock.err
+}
+
 func TestDdbUpdateWithExpression(t *testing.T) {
 	fixtureKey := map[string]types.AttributeValue{
 		"prefix": &types.AttributeValueMemberS{Value: "dead:beef"},
@@ -321,3 +480,270 @@ func TestDdbBatchGet(t *testing.T) {
 		)
 	})
 }
+
+func TestDdbTransactWrite(t *testing.T) {
+	name := ddb.ClauseFor[person, string]("Name")
+
+	expectVal := &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					Item:                     entityDynamo(),
+					TableName:                aws.String("test"),
+					ConditionExpression:      aws.String("(attribute_not_exists(#__c_name__))"),
+					ExpressionAttributeNames: map[string]string{"#__c_name__": "name"},
+				},
+			},
+			{
+				Delete: &types.Delete{
+					Key:       entityDynamoKey(),
+					TableName: aws.String("other"),
+				},
+			},
+			{
+				ConditionCheck: &types.ConditionCheck{
+					Key:                       entityDynamoKey(),
+					TableName:                 aws.String("test"),
+					ConditionExpression:       aws.String("(#__c_name__ = :__c_name__)"),
+					ExpressionAttributeNames:  map[string]string{"#__c_name__": "name"},
+					ExpressionAttributeValues: map[string]types.AttributeValue{":__c_name__": &types.AttributeValueMemberS{Value: "xxx"}},
+				},
+			},
+		},
+	}
+
+	t.Run("Commit", func(t *testing.T) {
+		mock := ddbtest.TransactWriteItems{
+			Mock: ddbtest.Mock[dynamodb.TransactWriteItemsInput, dynamodb.TransactWriteItemsOutput]{
+				ExpectVal: expectVal,
+				ReturnVal: &dynamodb.TransactWriteItemsOutput{},
+			},
+		}
+
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(mock)))
+		dbo := ddb.Must(ddb.New[dynamotest.Person]("other", ddb.WithDynamoDB(mock)))
+
+		err := ddb.TransactWrite(context.Background(),
+			ddb.TxPut(db, entityStruct(), name.NotExists()),
+			ddb.TxRemove(dbo, dynamotest.Person{Prefix: "dead:beef", Suffix: "1"}),
+			ddb.TxCheck(db, entityStructKey(), name.Eq("xxx")),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+		)
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		mock := ddbtest.TransactWriteItems{
+			Mock: ddbtest.Mock[dynamodb.TransactWriteItemsInput, dynamodb.TransactWriteItemsOutput]{
+				ExpectVal: expectVal,
+				ReturnErr: &types.TransactionCanceledException{
+					CancellationReasons: []types.CancellationReason{
+						{Code: aws.String("ConditionalCheckFailed")},
+						{Code: aws.String("None")},
+						{Code: aws.String("None")},
+					},
+				},
+			},
+		}
+
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(mock)))
+		dbo := ddb.Must(ddb.New[dynamotest.Person]("other", ddb.WithDynamoDB(mock)))
+
+		err := ddb.TransactWrite(context.Background(),
+			ddb.TxPut(db, entityStruct(), name.NotExists()),
+			ddb.TxRemove(dbo, dynamotest.Person{Prefix: "dead:beef", Suffix: "1"}),
+			ddb.TxCheck(db, entityStructKey(), name.Eq("xxx")),
+		)
+
+		pcf, ispcf := err.(interface{ PreConditionFailed() bool })
+		reasons, isreasons := err.(interface{ Reasons() []error })
+		conflict, isconflict := reasons.Reasons()[0].(interface{ Conflict() bool })
+		it.Then(t).Should(
+			it.True(ispcf),
+			it.True(pcf.PreConditionFailed()),
+			it.True(isreasons),
+			it.True(isconflict),
+			it.True(conflict.Conflict()),
+			it.Nil(reasons.Reasons()[1]),
+			it.Nil(reasons.Reasons()[2]),
+		)
+	})
+
+	t.Run("CheckWithoutCondition", func(t *testing.T) {
+		mock := ddbtest.TransactWriteItems{
+			Mock: ddbtest.Mock[dynamodb.TransactWriteItemsInput, dynamodb.TransactWriteItemsOutput]{
+				ReturnVal: &dynamodb.TransactWriteItemsOutput{},
+			},
+		}
+
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(mock)))
+
+		err := ddb.TransactWrite(context.Background(),
+			ddb.TxCheck(db, entityStructKey()),
+		)
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		)
+	})
+
+	t.Run("MixedServices", func(t *testing.T) {
+		mock := ddbtest.TransactWriteItems{
+			Mock: ddbtest.Mock[dynamodb.TransactWriteItemsInput, dynamodb.TransactWriteItemsOutput]{
+				ReturnVal: &dynamodb.TransactWriteItemsOutput{},
+			},
+		}
+		other := ddbtest.TransactWriteItems{
+			Mock: ddbtest.Mock[dynamodb.TransactWriteItemsInput, dynamodb.TransactWriteItemsOutput]{
+				ReturnVal: &dynamodb.TransactWriteItemsOutput{},
+			},
+		}
+
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(mock)))
+		dbo := ddb.Must(ddb.New[dynamotest.Person]("other", ddb.WithDynamoDB(other)))
+
+		err := ddb.TransactWrite(context.Background(),
+			ddb.TxPut(db, entityStruct()),
+			ddb.TxRemove(dbo, dynamotest.Person{Prefix: "dead:beef", Suffix: "1"}),
+		)
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		mock := ddbtest.TransactWriteItems{
+			Mock: ddbtest.Mock[dynamodb.TransactWriteItemsInput, dynamodb.TransactWriteItemsOutput]{
+				ReturnVal: &dynamodb.TransactWriteItemsOutput{},
+			},
+		}
+
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(mock)))
+
+		ops := make([]ddb.TxWrite, 101)
+		for i := range ops {
+			ops[i] = ddb.TxPut(db, entityStruct())
+		}
+
+		err := ddb.TransactWrite(context.Background(), ops...)
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		)
+
+		err = ddb.TransactWrite(context.Background(), ops[:100]...)
+		it.Then(t).Should(
+			it.Nil(err),
+		)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		mock := ddbtest.BatchWriteItem{
+			Mock: ddbtest.Mock[dynamodb.BatchWriteItemInput, dynamodb.BatchWriteItemOutput]{
+				ReturnVal: &dynamodb.BatchWriteItemOutput{},
+			},
+		}
+
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(mock)))
+
+		err := ddb.TransactWrite(context.Background(),
+			ddb.TxPut(db, entityStruct()),
+		)
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		)
+	})
+}
+
+func TestDdbTransactGet(t *testing.T) {
+	expectVal := &dynamodb.TransactGetItemsInput{
+		TransactItems: []types.TransactGetItem{
+			{Get: &types.Get{Key: entityDynamoKey(), TableName: aws.String("test")}},
+			{Get: &types.Get{Key: entityDynamoKey(), TableName: aws.String("other")}},
+		},
+	}
+
+	t.Run("Get", func(t *testing.T) {
+		mock := ddbtest.TransactGetItems{
+			Mock: ddbtest.Mock[dynamodb.TransactGetItemsInput, dynamodb.TransactGetItemsOutput]{
+				ExpectVal: expectVal,
+				ReturnVal: &dynamodb.TransactGetItemsOutput{
+					Responses: []types.ItemResponse{
+						{Item: entityDynamo()},
+						{Item: entityDynamo()},
+					},
+				},
+			},
+		}
+
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(mock)))
+		dbo := ddb.Must(ddb.New[dynamotest.Person]("other", ddb.WithDynamoDB(mock)))
+
+		var a person
+		var b dynamotest.Person
+		err := ddb.TransactGet(context.Background(),
+			ddb.TxGet(db, entityStructKey(), &a),
+			ddb.TxGet(dbo, dynamotest.Person{Prefix: "dead:beef", Suffix: "1"}, &b),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(a, entityStruct()),
+			it.Equiv(b, dynamotest.Person(entityStruct())),
+		)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		mock := ddbtest.TransactGetItems{
+			Mock: ddbtest.Mock[dynamodb.TransactGetItemsInput, dynamodb.TransactGetItemsOutput]{
+				ExpectVal: expectVal,
+				ReturnVal: &dynamodb.TransactGetItemsOutput{
+					Responses: []types.ItemResponse{
+						{Item: entityDynamo()},
+						{},
+					},
+				},
+			},
+		}
+
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(mock)))
+		dbo := ddb.Must(ddb.New[dynamotest.Person]("other", ddb.WithDynamoDB(mock)))
+
+		var a person
+		var b dynamotest.Person
+		err := ddb.TransactGet(context.Background(),
+			ddb.TxGet(db, entityStructKey(), &a),
+			ddb.TxGet(dbo, dynamotest.Person{Prefix: "dead:beef", Suffix: "1"}, &b),
+		)
+		_, isnfe := err.(interface{ NotFound() string })
+		it.Then(t).Should(
+			it.True(isnfe),
+			it.Equiv(a, entityStruct()),
+			it.Equiv(b, dynamotest.Person{}),
+		)
+	})
+
+	t.Run("MixedServices", func(t *testing.T) {
+		mock := ddbtest.TransactGetItems{
+			Mock: ddbtest.Mock[dynamodb.TransactGetItemsInput, dynamodb.TransactGetItemsOutput]{
+				ReturnVal: &dynamodb.TransactGetItemsOutput{},
+			},
+		}
+		other := ddbtest.TransactGetItems{
+			Mock: ddbtest.Mock[dynamodb.TransactGetItemsInput, dynamodb.TransactGetItemsOutput]{
+				ReturnVal: &dynamodb.TransactGetItemsOutput{},
+			},
+		}
+
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(mock)))
+		dbo := ddb.Must(ddb.New[dynamotest.Person]("other", ddb.WithDynamoDB(other)))
+
+		var a person
+		var b dynamotest.Person
+		err := ddb.TransactGet(context.Background(),
+			ddb.TxGet(db, entityStructKey(), &a),
+			ddb.TxGet(dbo, dynamotest.Person{Prefix: "dead:beef", Suffix: "1"}, &b),
+		)
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		)
+	})
+}
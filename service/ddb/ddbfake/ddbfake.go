@@ -0,0 +1,252 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+// Package ddbfake implements in-memory fake of AWS DynamoDB API, it is
+// compatible with ddb.DynamoDB interface. The fake evaluates expressions
+// (key condition, condition, filter, update and projection) generated by
+// the library and returns errors as AWS DynamoDB does, which allows
+// hermetic testing of ddb.Storage:
+//
+//	db := ddb.Must(ddb.New[Person]("test", ddb.WithDynamoDB(ddbfake.New())))
+package ddbfake
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/aws/smithy-go"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"github.com/fogfish/opts"
+)
+
+// Option type to configure the fake
+type Option = opts.Option[Options]
+
+// Config Options
+type Options struct {
+	hashKey string
+	sortKey string
+	indexes map[string]keySchema
+}
+
+type keySchema struct{ hashKey, sortKey string }
+
+var (
+	// Set name of partition key attribute, default is "prefix"
+	WithHashKey = opts.ForName[Options, string]("hashKey")
+
+	// Set name of sort key attribute, default is "suffix"
+	WithSortKey = opts.ForName[Options, string]("sortKey")
+)
+
+// Define global secondary index with partition and sort key attributes
+func WithGlobalSecondaryIndex(name, hashKey, sortKey string) Option {
+	return opts.Type[Options](func(o *Options) error {
+		o.indexes[name] = keySchema{hashKey: hashKey, sortKey: sortKey}
+		return nil
+	})
+}
+
+// DynamoDB is in-memory fake of AWS DynamoDB API
+type DynamoDB struct {
+	Options
+	mu     sync.Mutex
+	tables map[string]*table
+}
+
+// New creates instance of the fake
+func New(opt ...Option) *DynamoDB {
+	conf := Options{
+		hashKey: "prefix",
+		sortKey: "suffix",
+		indexes: map[string]keySchema{},
+	}
+
+	// The fake is used by tests, invalid options are programming errors
+	if err := opts.Apply(&conf, opt); err != nil {
+		panic(err)
+	}
+
+	return &DynamoDB{Options: conf, tables: map[string]*table{}}
+}
+
+// table keeps items indexed by primary key
+type table struct {
+	items map[string]ddbexpr.Item
+}
+
+func (db *DynamoDB) table(name *string) (*table, error) {
+	if name == nil || *name == "" {
+		return nil, errValidation("table name is not defined")
+	}
+
+	t, has := db.tables[*name]
+	if !has {
+		t = &table{items: map[string]ddbexpr.Item{}}
+		db.tables[*name] = t
+	}
+
+	return t, nil
+}
+
+// schema of the table or index
+func (db *DynamoDB) schema(index *string) (keySchema, error) {
+	if index == nil || *index == "" {
+		return keySchema{hashKey: db.hashKey, sortKey: db.sortKey}, nil
+	}
+
+	s, has := db.indexes[*index]
+	if !has {
+		return keySchema{}, errValidation("index " + *index + " is not defined")
+	}
+
+	return s, nil
+}
+
+// encodes primary key of the item
+func (db *DynamoDB) keyOf(item ddbexpr.Item) (string, error) {
+	hkey, has := item[db.hashKey]
+	if !has {
+		return "", errValidation("missing key attribute " + db.hashKey)
+	}
+
+	key := attrToString(hkey)
+	if db.sortKey != "" {
+		skey, has := item[db.sortKey]
+		if !has {
+			return "", errValidation("missing key attribute " + db.sortKey)
+		}
+		key += "\x00" + attrToString(skey)
+	}
+
+	return key, nil
+}
+
+// primary key and index key attributes of the item
+func (db *DynamoDB) lastEvaluatedKey(s keySchema, item ddbexpr.Item) ddbexpr.Item {
+	key := ddbexpr.Item{}
+	for _, attr := range []string{db.hashKey, db.sortKey, s.hashKey, s.sortKey} {
+		if v, has := item[attr]; has && attr != "" {
+			key[attr] = v
+		}
+	}
+	return key
+}
+
+// sorted items of the table or index, items without index key are skipped
+func (db *DynamoDB) sorted(t *table, s keySchema) []ddbexpr.Item {
+	seq := make([]ddbexpr.Item, 0, len(t.items))
+	for _, item := range t.items {
+		if _, has := item[s.hashKey]; !has {
+			continue
+		}
+		if _, has := item[s.sortKey]; s.sortKey != "" && !has {
+			continue
+		}
+		seq = append(seq, item)
+	}
+
+	attrs := []string{s.hashKey, s.sortKey, db.hashKey, db.sortKey}
+	slices.SortFunc(seq, func(a, b ddbexpr.Item) int {
+		return compareKey(a, b, attrs)
+	})
+
+	return seq
+}
+
+func compareKey(a, b ddbexpr.Item, attrs []string) int {
+	for _, attr := range attrs {
+		if c := compareAttr(a[attr], b[attr]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+func compareAttr(a, b types.AttributeValue) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	if c, ok := ddbexpr.Compare(a, b); ok {
+		return c
+	}
+	return strings.Compare(attrToString(a), attrToString(b))
+}
+
+func attrToString(a types.AttributeValue) string {
+	switch v := a.(type) {
+	case *types.AttributeValueMemberS:
+		return "S:" + v.Value
+	case *types.AttributeValueMemberN:
+		return "N:" + v.Value
+	case *types.AttributeValueMemberB:
+		return "B:" + string(v.Value)
+	}
+	return fmt.Sprintf("%T:%v", a, a)
+}
+
+func clone(item ddbexpr.Item) ddbexpr.Item {
+	if item == nil {
+		return nil
+	}
+	return maps.Clone(item)
+}
+
+// returns attributes of updated item as defined by ReturnValue
+func returnValues(rv types.ReturnValue, old, new ddbexpr.Item) ddbexpr.Item {
+	switch rv {
+	case types.ReturnValueAllOld:
+		return clone(old)
+	case types.ReturnValueAllNew:
+		return clone(new)
+	case types.ReturnValueUpdatedOld:
+		return changed(old, new)
+	case types.ReturnValueUpdatedNew:
+		return changed(new, old)
+	}
+	return nil
+}
+
+// attributes of a, which are changed in b
+func changed(a, b ddbexpr.Item) ddbexpr.Item {
+	out := ddbexpr.Item{}
+	for k, v := range a {
+		if w, has := b[k]; !has || !ddbexpr.Equal(v, w) {
+			out[k] = v
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func errValidation(msg string) error {
+	return &smithy.GenericAPIError{Code: "ValidationException", Message: msg}
+}
+
+func errConditionalCheckFailed(item ddbexpr.Item, rv types.ReturnValuesOnConditionCheckFailure) error {
+	err := &types.ConditionalCheckFailedException{
+		Message: aws.String("The conditional request failed"),
+	}
+	if rv == types.ReturnValuesOnConditionCheckFailureAllOld {
+		err.Item = clone(item)
+	}
+	return err
+}
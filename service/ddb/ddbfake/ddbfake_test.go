@@ -0,0 +1,271 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddbfake_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/kvtest"
+	"github.com/fogfish/dynamo/v3/service/ddb"
+	"github.com/fogfish/dynamo/v3/service/ddb/ddbfake"
+	"github.com/fogfish/faults"
+	"github.com/fogfish/it/v2"
+)
+
+type Note struct {
+	Prefix  curie.IRI `dynamodbav:"prefix,omitempty"`
+	Suffix  curie.IRI `dynamodbav:"suffix,omitempty"`
+	Owner   curie.IRI `dynamodbav:"owner,omitempty"`
+	Created string    `dynamodbav:"created,omitempty"`
+	Text    string    `dynamodbav:"text,omitempty"`
+	Likes   int       `dynamodbav:"likes,omitempty"`
+	Tags    []string  `dynamodbav:"tags,omitempty,stringset"`
+}
+
+func (n Note) HashKey() curie.IRI { return n.Prefix }
+func (n Note) SortKey() curie.IRI { return n.Suffix }
+
+// NoteByOwner is projection of notes to global secondary index
+type NoteByOwner Note
+
+func (n NoteByOwner) HashKey() curie.IRI { return n.Owner }
+func (n NoteByOwner) SortKey() curie.IRI { return curie.IRI(n.Created) }
+
+var (
+	text  = ddb.ClauseFor[Note, string]("Text")
+	likes = ddb.ClauseFor[Note, int]("Likes")
+
+	updateLikes = ddb.UpdateFor[Note, int]("Likes")
+	updateTags  = ddb.UpdateFor[Note, []string]("Tags")
+)
+
+func fixture(t *testing.T) (*ddbfake.DynamoDB, *ddb.Storage[Note]) {
+	t.Helper()
+
+	fake := ddbfake.New(ddbfake.WithGlobalSecondaryIndex("owner", "owner", "created"))
+	db := ddb.Must(ddb.New[Note]("notes", ddb.WithDynamoDB(fake)))
+
+	for i := 0; i < 5; i++ {
+		err := db.Put(context.Background(), Note{
+			Prefix:  "note:a",
+			Suffix:  curie.IRI("n:" + strconv.Itoa(i)),
+			Owner:   "user:x",
+			Created: "2024-01-0" + strconv.Itoa(i+1),
+			Text:    "text " + strconv.Itoa(i),
+			Likes:   i,
+		})
+		it.Then(t).Should(it.Nil(err))
+	}
+
+	return fake, db
+}
+
+func TestCrud(t *testing.T) {
+	_, db := fixture(t)
+	key := Note{Prefix: "note:a", Suffix: "n:1"}
+
+	val, err := db.Get(context.Background(), key)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(val.Text, "text 1"),
+	)
+
+	_, err = db.Get(context.Background(), Note{Prefix: "note:a", Suffix: "n:9"})
+	it.Then(t).Should(it.True(faults.IsNotFound(err)))
+
+	val, err = db.Update(context.Background(), Note{Prefix: "note:a", Suffix: "n:1", Text: "new"})
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(val.Text, "new"),
+		it.Equal(val.Likes, 1),
+	)
+
+	val, err = db.Remove(context.Background(), key)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(val.Text, "new"),
+	)
+
+	_, err = db.Get(context.Background(), key)
+	it.Then(t).Should(it.True(faults.IsNotFound(err)))
+}
+
+func TestCondition(t *testing.T) {
+	_, db := fixture(t)
+	val := Note{Prefix: "note:a", Suffix: "n:1", Text: "text 1", Likes: 1}
+
+	err := db.Put(context.Background(), val, text.NotExists())
+	it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+
+	err = db.Put(context.Background(), val, text.Eq("text 1"))
+	it.Then(t).Should(it.Nil(err))
+
+	_, err = db.Update(context.Background(), val, likes.Gt(10))
+	it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+
+	_, err = db.Remove(context.Background(), val, ddb.OneOf(likes.Gt(10), text.Eq("text 2")))
+	it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+}
+
+func TestUpdateWith(t *testing.T) {
+	_, db := fixture(t)
+
+	val, err := db.UpdateWith(context.Background(),
+		ddb.Updater(Note{Prefix: "note:a", Suffix: "n:2"},
+			updateLikes.Inc(10),
+			updateTags.Union([]string{"a", "b"}),
+		),
+		text.Exists(),
+	)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(val.Likes, 12),
+		it.Seq(val.Tags).Equal("a", "b"),
+	)
+
+	_, err = db.UpdateWith(context.Background(),
+		ddb.Updater(Note{Prefix: "note:a", Suffix: "n:9"}, updateLikes.Inc(1)),
+		text.Exists(),
+	)
+	it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+}
+
+func TestMatch(t *testing.T) {
+	fake, db := fixture(t)
+
+	t.Run("Paging", func(t *testing.T) {
+		seq, cur, err := db.Match(context.Background(), Note{Prefix: "note:a", Suffix: "n:"},
+			dynamo.Limit[Note](3),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 3),
+		)
+
+		seq, cur, err = db.Match(context.Background(), Note{Prefix: "note:a", Suffix: "n:"},
+			dynamo.Limit[Note](3), cur,
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 2),
+			it.Equal(seq[0].Suffix, "n:3"),
+			it.True(cur == nil),
+		)
+	})
+
+	t.Run("KeyConditionFilterReverse", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Note{Prefix: "note:a"},
+			ddb.SortKey[Note]().Between("n:1", "n:3"),
+			ddb.Filter(likes.Ge(2)),
+			dynamo.Reverse[Note](),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 2),
+			it.Equal(seq[0].Suffix, "n:3"),
+		)
+	})
+
+	t.Run("FilterRange", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Note{Prefix: "note:a"},
+			ddb.Filter(likes.Ge(1), likes.Le(2)),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 2),
+			it.Equal(seq[0].Suffix, "n:1"),
+			it.Equal(seq[1].Suffix, "n:2"),
+		)
+	})
+
+	t.Run("GlobalSecondaryIndex", func(t *testing.T) {
+		idx := ddb.Must(ddb.New[NoteByOwner]("notes",
+			ddb.WithDynamoDB(fake),
+			ddb.WithGlobalSecondaryIndex("owner"),
+			ddb.WithHashKey("owner"),
+			ddb.WithSortKey("created"),
+		))
+
+		n := 0
+		for x, err := range idx.MatchSeq(context.Background(), NoteByOwner{Owner: "user:x"},
+			dynamo.Limit[NoteByOwner](2),
+		) {
+			it.Then(t).Should(
+				it.Nil(err),
+				it.Equal(x.Created, "2024-01-0"+strconv.Itoa(n+1)),
+			)
+			n++
+		}
+		it.Then(t).Should(it.Equal(n, 5))
+	})
+
+	t.Run("KeysOnly", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Note{Prefix: "note:a"},
+			dynamo.KeysOnly[Note](),
+			ddb.Filter(likes.Ge(3)),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Seq(seq).Equal(
+				Note{Prefix: "note:a", Suffix: "n:3"},
+				Note{Prefix: "note:a", Suffix: "n:4"},
+			),
+		)
+	})
+
+	t.Run("Scan", func(t *testing.T) {
+		seq, err := db.ScanParallel(context.Background(), 3)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 5),
+		)
+	})
+}
+
+func TestBatchAndTransact(t *testing.T) {
+	_, db := fixture(t)
+
+	seq, err := db.BatchGet(context.Background(), []Note{
+		{Prefix: "note:a", Suffix: "n:0"},
+		{Prefix: "note:a", Suffix: "n:1"},
+		{Prefix: "note:a", Suffix: "n:9"},
+	})
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(len(seq), 2),
+	)
+
+	err = ddb.TransactWrite(context.Background(),
+		ddb.TxPut(db, Note{Prefix: "note:b", Suffix: "n:0"}),
+		ddb.TxCheck(db, Note{Prefix: "note:a", Suffix: "n:9"}, text.Exists()),
+	)
+	it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+
+	_, err = db.Get(context.Background(), Note{Prefix: "note:b", Suffix: "n:0"})
+	it.Then(t).Should(it.True(faults.IsNotFound(err)))
+
+	err = ddb.TransactWrite(context.Background(),
+		ddb.TxPut(db, Note{Prefix: "note:b", Suffix: "n:0"}),
+		ddb.TxRemove(db, Note{Prefix: "note:a", Suffix: "n:0"}),
+	)
+	it.Then(t).Should(it.Nil(err))
+
+	_, err = db.Get(context.Background(), Note{Prefix: "note:b", Suffix: "n:0"})
+	it.Then(t).Should(it.Nil(err))
+}
+
+func TestConformance(t *testing.T) {
+	kvtest.TestKeyVal(t, func() dynamo.KeyVal[kvtest.Person] {
+		return ddb.Must(ddb.New[kvtest.Person]("test", ddb.WithDynamoDB(ddbfake.New())))
+	})
+}
@@ -0,0 +1,533 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddbfake
+
+import (
+	"context"
+	"hash/fnv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+)
+
+// GetItem reads item by primary key
+func (db *DynamoDB) GetItem(ctx context.Context, input *dynamodb.GetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := db.keyOf(input.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := ddbexpr.Projection(
+		aws.ToString(input.ProjectionExpression),
+		input.ExpressionAttributeNames,
+		t.items[key],
+	)
+	if err != nil {
+		return nil, errValidation(err.Error())
+	}
+
+	return &dynamodb.GetItemOutput{Item: clone(item)}, nil
+}
+
+// PutItem writes item
+func (db *DynamoDB) PutItem(ctx context.Context, input *dynamodb.PutItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	old, err := db.put(&types.Put{
+		TableName:                           input.TableName,
+		Item:                                input.Item,
+		ConditionExpression:                 input.ConditionExpression,
+		ExpressionAttributeNames:            input.ExpressionAttributeNames,
+		ExpressionAttributeValues:           input.ExpressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: input.ReturnValuesOnConditionCheckFailure,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.PutItemOutput{
+		Attributes: returnValues(input.ReturnValues, old, nil),
+	}, nil
+}
+
+func (db *DynamoDB) put(input *types.Put, dryRun bool) (ddbexpr.Item, error) {
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := db.keyOf(input.Item)
+	if err != nil {
+		return nil, err
+	}
+
+	old := t.items[key]
+	if err := checkCondition(input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, old, input.ReturnValuesOnConditionCheckFailure); err != nil {
+		return nil, err
+	}
+
+	if !dryRun {
+		t.items[key] = clone(input.Item)
+	}
+	return old, nil
+}
+
+// DeleteItem removes item by primary key
+func (db *DynamoDB) DeleteItem(ctx context.Context, input *dynamodb.DeleteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	old, err := db.delete(&types.Delete{
+		TableName:                           input.TableName,
+		Key:                                 input.Key,
+		ConditionExpression:                 input.ConditionExpression,
+		ExpressionAttributeNames:            input.ExpressionAttributeNames,
+		ExpressionAttributeValues:           input.ExpressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: input.ReturnValuesOnConditionCheckFailure,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.DeleteItemOutput{
+		Attributes: returnValues(input.ReturnValues, old, nil),
+	}, nil
+}
+
+func (db *DynamoDB) delete(input *types.Delete, dryRun bool) (ddbexpr.Item, error) {
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := db.keyOf(input.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	old := t.items[key]
+	if err := checkCondition(input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, old, input.ReturnValuesOnConditionCheckFailure); err != nil {
+		return nil, err
+	}
+
+	if !dryRun {
+		delete(t.items, key)
+	}
+	return old, nil
+}
+
+// UpdateItem applies update expression to item, the item is created if
+// it does not exist.
+func (db *DynamoDB) UpdateItem(ctx context.Context, input *dynamodb.UpdateItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	old, new, err := db.update(&types.Update{
+		TableName:                           input.TableName,
+		Key:                                 input.Key,
+		UpdateExpression:                    input.UpdateExpression,
+		ConditionExpression:                 input.ConditionExpression,
+		ExpressionAttributeNames:            input.ExpressionAttributeNames,
+		ExpressionAttributeValues:           input.ExpressionAttributeValues,
+		ReturnValuesOnConditionCheckFailure: input.ReturnValuesOnConditionCheckFailure,
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.UpdateItemOutput{
+		Attributes: returnValues(input.ReturnValues, old, new),
+	}, nil
+}
+
+func (db *DynamoDB) update(input *types.Update, dryRun bool) (ddbexpr.Item, ddbexpr.Item, error) {
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := db.keyOf(input.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	old := t.items[key]
+	if err := checkCondition(input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, old, input.ReturnValuesOnConditionCheckFailure); err != nil {
+		return nil, nil, err
+	}
+
+	base := old
+	if base == nil {
+		base = input.Key
+	}
+
+	new, err := ddbexpr.Update(
+		aws.ToString(input.UpdateExpression),
+		input.ExpressionAttributeNames,
+		input.ExpressionAttributeValues,
+		base,
+	)
+	if err != nil {
+		return nil, nil, errValidation(err.Error())
+	}
+
+	// key attributes cannot be updated
+	for k, v := range input.Key {
+		if w, has := new[k]; !has || !ddbexpr.Equal(v, w) {
+			return nil, nil, errValidation("cannot update key attribute " + k)
+		}
+	}
+
+	if !dryRun {
+		t.items[key] = new
+	}
+	return old, new, nil
+}
+
+func (db *DynamoDB) conditionCheck(input *types.ConditionCheck) error {
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return err
+	}
+
+	key, err := db.keyOf(input.Key)
+	if err != nil {
+		return err
+	}
+
+	return checkCondition(input.ConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, t.items[key], input.ReturnValuesOnConditionCheckFailure)
+}
+
+func checkCondition(
+	expr *string,
+	names map[string]string,
+	values map[string]types.AttributeValue,
+	item ddbexpr.Item,
+	rv types.ReturnValuesOnConditionCheckFailure,
+) error {
+	if expr == nil {
+		return nil
+	}
+
+	ok, err := ddbexpr.Condition(*expr, names, values, item)
+	if err != nil {
+		return errValidation(err.Error())
+	}
+
+	if !ok {
+		return errConditionalCheckFailed(item, rv)
+	}
+
+	return nil
+}
+
+// Query reads items of the partition matching key condition expression
+func (db *DynamoDB) Query(ctx context.Context, input *dynamodb.QueryInput, opts ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := db.schema(input.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.KeyConditionExpression == nil {
+		return nil, errValidation("key condition expression is not defined")
+	}
+
+	var seq []ddbexpr.Item
+	for _, item := range db.sorted(t, s) {
+		ok, err := ddbexpr.Condition(*input.KeyConditionExpression, input.ExpressionAttributeNames, input.ExpressionAttributeValues, item)
+		if err != nil {
+			return nil, errValidation(err.Error())
+		}
+		if ok {
+			seq = append(seq, item)
+		}
+	}
+
+	if input.ScanIndexForward != nil && !*input.ScanIndexForward {
+		for i, j := 0, len(seq)-1; i < j; i, j = i+1, j-1 {
+			seq[i], seq[j] = seq[j], seq[i]
+		}
+	}
+
+	items, scanned, last, err := db.page(seq, s, input.ExclusiveStartKey, input.Limit,
+		aws.ToBool(input.ScanIndexForward) || input.ScanIndexForward == nil,
+		input.FilterExpression, input.ProjectionExpression,
+		input.ExpressionAttributeNames, input.ExpressionAttributeValues,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.QueryOutput{
+		Items:            items,
+		Count:            int32(len(items)),
+		ScannedCount:     scanned,
+		LastEvaluatedKey: last,
+	}, nil
+}
+
+// Scan reads all items of the table or segment
+func (db *DynamoDB) Scan(ctx context.Context, input *dynamodb.ScanInput, opts ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	t, err := db.table(input.TableName)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := db.schema(input.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	var seq []ddbexpr.Item
+	for _, item := range db.sorted(t, s) {
+		if input.TotalSegments != nil && segmentOf(item[s.hashKey], *input.TotalSegments) != aws.ToInt32(input.Segment) {
+			continue
+		}
+		seq = append(seq, item)
+	}
+
+	items, scanned, last, err := db.page(seq, s, input.ExclusiveStartKey, input.Limit, true,
+		input.FilterExpression, input.ProjectionExpression,
+		input.ExpressionAttributeNames, input.ExpressionAttributeValues,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dynamodb.ScanOutput{
+		Items:            items,
+		Count:            int32(len(items)),
+		ScannedCount:     scanned,
+		LastEvaluatedKey: last,
+	}, nil
+}
+
+func segmentOf(hashKey types.AttributeValue, totalSegments int32) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(attrToString(hashKey)))
+	return int32(h.Sum32() % uint32(max(totalSegments, 1)))
+}
+
+// page reads items after exclusive start key, the limit is number of
+// evaluated items before filter expression is applied.
+func (db *DynamoDB) page(
+	seq []ddbexpr.Item,
+	s keySchema,
+	exclusiveStartKey ddbexpr.Item,
+	limit *int32,
+	forward bool,
+	filterExpression *string,
+	projectionExpression *string,
+	names map[string]string,
+	values map[string]types.AttributeValue,
+) ([]map[string]types.AttributeValue, int32, ddbexpr.Item, error) {
+	attrs := []string{s.hashKey, s.sortKey, db.hashKey, db.sortKey}
+
+	at := 0
+	if len(exclusiveStartKey) != 0 {
+		for at < len(seq) {
+			c := compareKey(seq[at], exclusiveStartKey, attrs)
+			if (forward && c > 0) || (!forward && c < 0) {
+				break
+			}
+			at++
+		}
+	}
+
+	var (
+		items   []map[string]types.AttributeValue
+		scanned int32
+		last    ddbexpr.Item
+	)
+
+	for ; at < len(seq); at++ {
+		if limit != nil && scanned == *limit {
+			return items, scanned, last, nil
+		}
+
+		item := seq[at]
+		scanned++
+		last = db.lastEvaluatedKey(s, item)
+
+		if filterExpression != nil {
+			ok, err := ddbexpr.Condition(*filterExpression, names, values, item)
+			if err != nil {
+				return nil, 0, nil, errValidation(err.Error())
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		val, err := ddbexpr.Projection(aws.ToString(projectionExpression), names, item)
+		if err != nil {
+			return nil, 0, nil, errValidation(err.Error())
+		}
+		items = append(items, clone(val))
+	}
+
+	return items, scanned, nil, nil
+}
+
+// BatchGetItem reads multiple items, all keys are processed
+func (db *DynamoDB) BatchGetItem(ctx context.Context, input *dynamodb.BatchGetItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.BatchGetItemOutput, error) {
+	out := &dynamodb.BatchGetItemOutput{
+		Responses: map[string][]map[string]types.AttributeValue{},
+	}
+
+	for name, req := range input.RequestItems {
+		for _, key := range req.Keys {
+			val, err := db.GetItem(ctx, &dynamodb.GetItemInput{
+				TableName:                aws.String(name),
+				Key:                      key,
+				ProjectionExpression:     req.ProjectionExpression,
+				ExpressionAttributeNames: req.ExpressionAttributeNames,
+			})
+			if err != nil {
+				return nil, err
+			}
+			if val.Item != nil {
+				out.Responses[name] = append(out.Responses[name], val.Item)
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// BatchWriteItem writes or removes multiple items, all requests are processed
+func (db *DynamoDB) BatchWriteItem(ctx context.Context, input *dynamodb.BatchWriteItemInput, opts ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	for name, seq := range input.RequestItems {
+		for _, req := range seq {
+			var err error
+			switch {
+			case req.PutRequest != nil:
+				_, err = db.put(&types.Put{TableName: aws.String(name), Item: req.PutRequest.Item}, false)
+			case req.DeleteRequest != nil:
+				_, err = db.delete(&types.Delete{TableName: aws.String(name), Key: req.DeleteRequest.Key}, false)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &dynamodb.BatchWriteItemOutput{}, nil
+}
+
+// TransactGetItems reads multiple items atomically
+func (db *DynamoDB) TransactGetItems(ctx context.Context, input *dynamodb.TransactGetItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error) {
+	out := &dynamodb.TransactGetItemsOutput{
+		Responses: make([]types.ItemResponse, len(input.TransactItems)),
+	}
+
+	for i, req := range input.TransactItems {
+		if req.Get == nil {
+			return nil, errValidation("get is not defined")
+		}
+
+		val, err := db.GetItem(ctx, &dynamodb.GetItemInput{
+			TableName:                req.Get.TableName,
+			Key:                      req.Get.Key,
+			ProjectionExpression:     req.Get.ProjectionExpression,
+			ExpressionAttributeNames: req.Get.ExpressionAttributeNames,
+		})
+		if err != nil {
+			return nil, err
+		}
+		out.Responses[i] = types.ItemResponse{Item: val.Item}
+	}
+
+	return out, nil
+}
+
+// TransactWriteItems applies multiple writes atomically, the transaction
+// is cancelled if any of conditions fails.
+func (db *DynamoDB) TransactWriteItems(ctx context.Context, input *dynamodb.TransactWriteItemsInput, opts ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	reasons := make([]types.CancellationReason, len(input.TransactItems))
+	cancelled := false
+
+	for i, req := range input.TransactItems {
+		var err error
+		switch {
+		case req.Put != nil:
+			_, err = db.put(req.Put, true)
+		case req.Update != nil:
+			_, _, err = db.update(req.Update, true)
+		case req.Delete != nil:
+			_, err = db.delete(req.Delete, true)
+		case req.ConditionCheck != nil:
+			err = db.conditionCheck(req.ConditionCheck)
+		default:
+			return nil, errValidation("transact item is not defined")
+		}
+
+		reasons[i] = types.CancellationReason{Code: aws.String("None")}
+		if err != nil {
+			e, ok := err.(*types.ConditionalCheckFailedException)
+			if !ok {
+				return nil, err
+			}
+			cancelled = true
+			reasons[i] = types.CancellationReason{
+				Code:    aws.String("ConditionalCheckFailed"),
+				Message: e.Message,
+				Item:    e.Item,
+			}
+		}
+	}
+
+	if cancelled {
+		return nil, &types.TransactionCanceledException{
+			Message:             aws.String("Transaction cancelled"),
+			CancellationReasons: reasons,
+		}
+	}
+
+	for _, req := range input.TransactItems {
+		switch {
+		case req.Put != nil:
+			db.put(req.Put, false)
+		case req.Update != nil:
+			db.update(req.Update, false)
+		case req.Delete != nil:
+			db.delete(req.Delete, false)
+		}
+	}
+
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/updateexpr"
 	"github.com/fogfish/golem/hseq"
 )
 
@@ -41,6 +42,22 @@ type UpdateItemExpression[T dynamo.Thing] struct {
 	request *dynamodb.UpdateItemInput
 }
 
+// expression for other storages, see updateexpr package
+func (e UpdateItemExpression[T]) updateExpression() updateexpr.Expression {
+	return updateexpr.Expression{
+		Entity:     e.entity,
+		Expression: aws.ToString(e.request.UpdateExpression),
+		Names:      e.request.ExpressionAttributeNames,
+		Values:     e.request.ExpressionAttributeValues,
+	}
+}
+
+func init() {
+	updateexpr.Of = func(e any) updateexpr.Expression {
+		return e.(interface{ updateExpression() updateexpr.Expression }).updateExpression()
+	}
+}
+
 type UpdateItemInput struct {
 	*dynamodb.UpdateItemInput
 	expr map[string][]string
@@ -9,62 +9,71 @@
 package ddb
 
 import (
+	"context"
 	"errors"
-	"fmt"
+	"strings"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/fogfish/dynamo/v3"
 	"github.com/fogfish/faults"
 )
 
 const (
-	errServiceIO      = faults.Type("service i/o failed")
-	errInvalidKey     = faults.Type("invalid key")
-	errInvalidEntity  = faults.Type("invalid entity")
-	errBatchPartialIO = faults.Type("batch i/o failed partially")
+	errServiceIO          = faults.Type("service i/o failed")
+	errInvalidKey         = faults.Type("invalid key")
+	errInvalidEntity      = faults.Type("invalid entity")
+	errBatchPartialIO     = faults.Type("batch i/o failed partially")
+	errUndefinedCondition = faults.Type("undefined condition")
+	errUnsupportedOpt     = faults.Safe1[string]("unsupported option %s")
+	errUnsupportedAPI     = faults.Safe1[string]("DynamoDB client does not implement %s")
+	errTxMixedServices    = faults.Type("transaction operations use different DynamoDB clients")
+	errTxTooLarge         = faults.Safe1[int]("transaction has %d operations, DynamoDB accepts up to 100")
+	errRetriesExhausted   = faults.Safe1[int]("retries exhausted, %d unprocessed items")
 )
 
-// NotFound is an error to handle unknown elements
-func errNotFound(err error, key dynamo.Thing) error {
-	return &notFound{err: err, Thing: key}
-}
-
-type notFound struct {
-	dynamo.Thing
-	err error
-}
-
-func (e *notFound) Error() string {
-	return fmt.Sprintf("Not Found (%s, %s)", e.HashKey(), e.SortKey())
+// errPartialBatch is caused either by the context (e.g. deadline) or
+// by exhausted retries
+func errPartialBatch(ctx context.Context, unprocessed int) error {
+	err := ctx.Err()
+	if err == nil {
+		err = errRetriesExhausted.New(nil, unprocessed)
+	}
+	return errBatchPartialIO.New(&dynamo.ServiceError{Kind: dynamo.ErrPartialBatch, Err: err})
 }
 
-func (e *notFound) Unwrap() error { return e.err }
-
-func (e *notFound) NotFound() string { return e.HashKey().Safe() + " " + e.SortKey().Safe() }
-
-// errPreConditionFailed
-func errPreConditionFailed(err error, thing dynamo.Thing, conflict bool, gone bool) error {
-	return &preConditionFailed{Thing: thing, conflict: conflict, gone: gone, err: err}
-}
-
-type preConditionFailed struct {
-	dynamo.Thing
-	conflict bool
-	gone     bool
-	err      error
+// NotFound is an error to handle unknown elements
+func errNotFound(err error, key dynamo.Thing) error {
+	return &dynamo.NotFoundError{Thing: key, Err: err}
 }
 
-func (e *preConditionFailed) Error() string {
-	return fmt.Sprintf("Pre Condition Failed (%s, %s)", e.HashKey(), e.SortKey())
+// errService classifies errors of AWS DynamoDB
+//
+//	https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Programming.Errors.html
+func errService(err error) error {
+	var e interface {
+		ErrorCode() string
+		ErrorMessage() string
+	}
+	if !errors.As(err, &e) {
+		return err
+	}
+
+	switch e.ErrorCode() {
+	case "ProvisionedThroughputExceededException", "ThrottlingException", "RequestLimitExceeded":
+		return &dynamo.ServiceError{Kind: dynamo.ErrThrottled, Err: err}
+	case "ItemCollectionSizeLimitExceededException":
+		return &dynamo.ServiceError{Kind: dynamo.ErrItemTooLarge, Err: err}
+	case "ValidationException":
+		// DynamoDB does not define own error code for large items
+		if strings.Contains(e.ErrorMessage(), "Item size") {
+			return &dynamo.ServiceError{Kind: dynamo.ErrItemTooLarge, Err: err}
+		}
+		return &dynamo.ServiceError{Kind: dynamo.ErrValidation, Err: err}
+	}
+
+	return err
 }
 
-func (e *preConditionFailed) PreConditionFailed() bool { return true }
-
-func (e *preConditionFailed) Conflict() bool { return e.conflict }
-
-func (e *preConditionFailed) Gone() bool { return e.gone }
-
-func (e *preConditionFailed) Unwrap() error { return e.err }
-
 // recover AWS ErrorCode
 func recoverConditionalCheckFailedException(err error) bool {
 	var e interface{ ErrorCode() string }
@@ -72,3 +81,40 @@ func recoverConditionalCheckFailedException(err error) bool {
 	ok := errors.As(err, &e)
 	return ok && e.ErrorCode() == "ConditionalCheckFailedException"
 }
+
+// errConditionFailed classifies the failed condition using failure semantic
+// declared by condition nodes. Conditions are joined with AND, the failed
+// one is identified only if current item is known, otherwise the semantic
+// holds if all conditions agree on it.
+func errConditionFailed(err error, thing dynamo.Thing, seq []condition, item map[string]types.AttributeValue) *dynamo.PreConditionFailedError {
+	f := allOf(seq)
+
+	if item != nil {
+		if failed := failedConditions(seq, item); len(failed) != 0 {
+			seq, f = failed, anyOf(failed)
+		}
+	}
+
+	clause := make([]string, len(seq))
+	for i, c := range seq {
+		clause[i] = c.String()
+	}
+
+	return &dynamo.PreConditionFailedError{
+		Thing:   thing,
+		Failure: dynamo.Failure(f.conflict, f.gone),
+		Failed:  strings.Join(clause, " and "),
+		Err:     err,
+	}
+}
+
+// conditions that are not satisfied by the item
+func failedConditions(seq []condition, item map[string]types.AttributeValue) []condition {
+	failed := make([]condition, 0, len(seq))
+	for _, c := range seq {
+		if x := c.eval(item); x.known && !x.ok {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
@@ -0,0 +1,139 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file implements dynamodb specific key conditions
+//
+
+package ddb
+
+import (
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+)
+
+// See DynamoDB Key Condition Expressions
+//
+//	https://docs.aws.amazon.com/amazondynamodb/latest/developerguide/Query.KeyConditionExpressions.html
+//
+// SortKey declares builder of key conditions over the sort key. The key
+// condition is used as MatcherOpt, it replaces the default `begins_with`
+// lookup of the sort key. The sort key of the pattern is ignored.
+//
+//	var ts = ddb.SortKey[Event]()
+//
+//	db.Match(ctx, Event{Stream: "x"}, ts.Between("2024-01-01", "2024-02-01"))
+func SortKey[T dynamo.Thing]() KeyConditionExpression[T] {
+	return KeyConditionExpression[T]{}
+}
+
+type KeyConditionExpression[T dynamo.Thing] struct{}
+
+// Eq is equal condition
+//
+//	sk.Eq(x) ⟼ SortKey = :value
+func (KeyConditionExpression[T]) Eq(val curie.IRI) interface{ MatcherOpt(T) } {
+	return &dyadicKeyCondition[T]{op: "=", val: val}
+}
+
+// Lt is less than condition
+//
+//	sk.Lt(x) ⟼ SortKey < :value
+func (KeyConditionExpression[T]) Lt(val curie.IRI) interface{ MatcherOpt(T) } {
+	return &dyadicKeyCondition[T]{op: "<", val: val}
+}
+
+// Le is less or equal condition
+//
+//	sk.Le(x) ⟼ SortKey <= :value
+func (KeyConditionExpression[T]) Le(val curie.IRI) interface{ MatcherOpt(T) } {
+	return &dyadicKeyCondition[T]{op: "<=", val: val}
+}
+
+// Gt is greater than condition
+//
+//	sk.Gt(x) ⟼ SortKey > :value
+func (KeyConditionExpression[T]) Gt(val curie.IRI) interface{ MatcherOpt(T) } {
+	return &dyadicKeyCondition[T]{op: ">", val: val}
+}
+
+// Ge is greater or equal condition
+//
+//	sk.Ge(x) ⟼ SortKey >= :value
+func (KeyConditionExpression[T]) Ge(val curie.IRI) interface{ MatcherOpt(T) } {
+	return &dyadicKeyCondition[T]{op: ">=", val: val}
+}
+
+// dyadic key condition implementation
+type dyadicKeyCondition[T any] struct {
+	op  string
+	val curie.IRI
+}
+
+func (op dyadicKeyCondition[T]) MatcherOpt(T) {}
+
+func (op dyadicKeyCondition[T]) KeyCondition(
+	key string,
+	expressionAttributeValues map[string]types.AttributeValue,
+) string {
+	let := ":__k_" + key + "__"
+	expressionAttributeValues[let] = &types.AttributeValueMemberS{Value: string(op.val)}
+
+	return key + " " + op.op + " " + let
+}
+
+// Between key condition
+//
+//	sk.Between(a, b) ⟼ SortKey BETWEEN :a AND :b
+func (KeyConditionExpression[T]) Between(a, b curie.IRI) interface{ MatcherOpt(T) } {
+	return &betweenKeyCondition[T]{a: a, b: b}
+}
+
+// between key condition implementation
+type betweenKeyCondition[T any] struct {
+	a, b curie.IRI
+}
+
+func (op betweenKeyCondition[T]) MatcherOpt(T) {}
+
+func (op betweenKeyCondition[T]) KeyCondition(
+	key string,
+	expressionAttributeValues map[string]types.AttributeValue,
+) string {
+	letA := ":__k_" + key + "_a__"
+	letB := ":__k_" + key + "_b__"
+	expressionAttributeValues[letA] = &types.AttributeValueMemberS{Value: string(op.a)}
+	expressionAttributeValues[letB] = &types.AttributeValueMemberS{Value: string(op.b)}
+
+	return key + " BETWEEN " + letA + " AND " + letB
+}
+
+// HasPrefix key condition
+//
+//	sk.HasPrefix(x) ⟼ begins_with(SortKey, :value)
+func (KeyConditionExpression[T]) HasPrefix(val curie.IRI) interface{ MatcherOpt(T) } {
+	return &prefixKeyCondition[T]{val: val}
+}
+
+// prefix key condition implementation
+type prefixKeyCondition[T any] struct {
+	val curie.IRI
+}
+
+func (op prefixKeyCondition[T]) MatcherOpt(T) {}
+
+func (op prefixKeyCondition[T]) KeyCondition(
+	key string,
+	expressionAttributeValues map[string]types.AttributeValue,
+) string {
+	let := ":__k_" + key + "__"
+	expressionAttributeValues[let] = &types.AttributeValueMemberS{Value: string(op.val)}
+
+	return "begins_with(" + key + ", " + let + ")"
+}
@@ -0,0 +1,73 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/it/v2"
+)
+
+type tKeyCondition struct {
+	Prefix curie.IRI `dynamodbav:"prefix,omitempty"`
+	Suffix curie.IRI `dynamodbav:"suffix,omitempty"`
+}
+
+func (x tKeyCondition) HashKey() curie.IRI { return x.Prefix }
+func (x tKeyCondition) SortKey() curie.IRI { return x.Suffix }
+
+func TestKeyCondition(t *testing.T) {
+	sk := SortKey[tKeyCondition]()
+	key := tKeyCondition{Prefix: "a", Suffix: "b"}
+	pk := &types.AttributeValueMemberS{Value: "a"}
+	v1 := &types.AttributeValueMemberS{Value: "1"}
+	v2 := &types.AttributeValueMemberS{Value: "2"}
+
+	for _, tc := range []struct {
+		opt  interface{ MatcherOpt(tKeyCondition) }
+		expr string
+		vals map[string]types.AttributeValue
+	}{
+		{sk.Eq("1"), "prefix = :__prefix__ and suffix = :__k_suffix__", map[string]types.AttributeValue{":__prefix__": pk, ":__k_suffix__": v1}},
+		{sk.Lt("1"), "prefix = :__prefix__ and suffix < :__k_suffix__", map[string]types.AttributeValue{":__prefix__": pk, ":__k_suffix__": v1}},
+		{sk.Le("1"), "prefix = :__prefix__ and suffix <= :__k_suffix__", map[string]types.AttributeValue{":__prefix__": pk, ":__k_suffix__": v1}},
+		{sk.Gt("1"), "prefix = :__prefix__ and suffix > :__k_suffix__", map[string]types.AttributeValue{":__prefix__": pk, ":__k_suffix__": v1}},
+		{sk.Ge("1"), "prefix = :__prefix__ and suffix >= :__k_suffix__", map[string]types.AttributeValue{":__prefix__": pk, ":__k_suffix__": v1}},
+		{sk.HasPrefix("1"), "prefix = :__prefix__ and begins_with(suffix, :__k_suffix__)", map[string]types.AttributeValue{":__prefix__": pk, ":__k_suffix__": v1}},
+		{sk.Between("1", "2"), "prefix = :__prefix__ and suffix BETWEEN :__k_suffix_a__ AND :__k_suffix_b__", map[string]types.AttributeValue{":__prefix__": pk, ":__k_suffix_a__": v1, ":__k_suffix_b__": v2}},
+	} {
+		mock := &queryRecorder{}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		_, _, err := db.Match(context.Background(), key, tc.opt)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*mock.req.KeyConditionExpression, tc.expr),
+			it.Equiv(mock.req.ExpressionAttributeValues, tc.vals),
+		)
+	}
+}
+
+func TestKeyConditionMultiple(t *testing.T) {
+	sk := SortKey[tKeyCondition]()
+	mock := &queryRecorder{}
+	db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+	_, _, err := db.Match(context.Background(), tKeyCondition{Prefix: "a"},
+		sk.Gt("1"), sk.Lt("2"),
+	)
+	it.Then(t).ShouldNot(
+		it.Nil(err),
+	).Should(
+		it.True(mock.req == nil),
+	)
+}
@@ -23,19 +23,29 @@ func (db *Storage[T]) Get(ctx context.Context, key T, opts ...interface{ GetterO
 		return db.undefined, errInvalidKey.New(err)
 	}
 
+	projection, names := db.schema.Projection, db.schema.ExpectedAttributeNames
+	skipExpired := skipExpiredOf(opts)
+	if skipExpired {
+		if db.ttlAttribute == "" {
+			return db.undefined, errUnsupportedOpt.New(nil, "SkipExpired without TTL attribute, see ddb.WithTTLAttribute")
+		}
+		projection, names = db.projectionWithTTL()
+	}
+
 	req := &dynamodb.GetItemInput{
 		Key:                      gen,
 		TableName:                aws.String(db.table),
-		ProjectionExpression:     db.schema.Projection,
-		ExpressionAttributeNames: db.schema.ExpectedAttributeNames,
+		ProjectionExpression:     projection,
+		ExpressionAttributeNames: names,
+		ConsistentRead:           consistentReadOf(opts),
 	}
 
 	val, err := db.service.GetItem(ctx, req)
 	if err != nil {
-		return db.undefined, errServiceIO.New(err)
+		return db.undefined, errServiceIO.New(errService(err))
 	}
 
-	if val.Item == nil {
+	if val.Item == nil || (skipExpired && db.isExpired(val.Item)) {
 		return db.undefined, errNotFound(nil, key)
 	}
 
@@ -47,6 +57,9 @@ func (db *Storage[T]) Get(ctx context.Context, key T, opts ...interface{ GetterO
 	return obj, nil
 }
 
+// BatchGet reads multiple items at once. Keys are split into chunks read concurrently,
+// unprocessed keys are retried with exponential backoff. Items read before
+// the failure are returned together with the error.
 func (db *Storage[T]) BatchGet(ctx context.Context, keys []T, opts ...interface{ GetterOpt(T) }) ([]T, error) {
 	if len(keys) == 0 {
 		return nil, nil
@@ -61,25 +74,7 @@ func (db *Storage[T]) BatchGet(ctx context.Context, keys []T, opts ...interface{
 		seq[i] = gen
 	}
 
-	req := &dynamodb.BatchGetItemInput{
-		RequestItems: map[string]types.KeysAndAttributes{
-			db.table: {
-				Keys:                     seq,
-				ProjectionExpression:     db.schema.Projection,
-				ExpressionAttributeNames: db.schema.ExpectedAttributeNames,
-			},
-		},
-	}
-
-	val, err := db.service.BatchGetItem(ctx, req)
-	if err != nil {
-		return nil, errServiceIO.New(err)
-	}
-
-	rsp, exists := val.Responses[db.table]
-	if !exists {
-		return make([]T, 0), nil
-	}
+	rsp, err := db.batchGetAll(ctx, seq, consistentReadOf(opts))
 
 	items := make([]T, len(rsp))
 	for i := 0; i < len(rsp); i++ {
@@ -90,5 +85,14 @@ func (db *Storage[T]) BatchGet(ctx context.Context, keys []T, opts ...interface{
 		items[i] = obj
 	}
 
-	return items, nil
+	return items, err
+}
+
+func consistentReadOf[T any](opts []interface{ GetterOpt(T) }) *bool {
+	for _, opt := range opts {
+		if v, ok := opt.(interface{ ConsistentRead() bool }); ok {
+			return aws.Bool(v.ConsistentRead())
+		}
+	}
+	return nil
 }
@@ -10,12 +10,16 @@ package ddb
 
 import (
 	"context"
+	"encoding/json"
+	"iter"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/fogfish/curie/v2"
 	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/iterator"
 )
 
 // Match applies a pattern matching to elements in the table
@@ -36,6 +40,17 @@ func (db *Storage[T]) Match(ctx context.Context, key T, opts ...interface{ Match
 	return db.match(ctx, gen, opts)
 }
 
+// MatchSeq lazily iterates over all elements matching the pattern, it
+// follows LastEvaluatedKey until the collection or MaxItems is exhausted.
+func (db *Storage[T]) MatchSeq(ctx context.Context, key T, opts ...interface{ MatcherOpt(T) }) iter.Seq2[T, error] {
+	return iterator.Seq(ctx,
+		func(ctx context.Context, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+			return db.Match(ctx, key, opts...)
+		},
+		opts,
+	)
+}
+
 // Match applies a pattern matching to elements in the table
 func (db *Storage[T]) match(ctx context.Context, gen map[string]types.AttributeValue, opts []interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
 	suffix, isSuffix := gen[db.codec.skSuffix]
@@ -47,15 +62,44 @@ func (db *Storage[T]) match(ctx context.Context, gen map[string]types.AttributeV
 		}
 	}
 
+	var keyCondition interface {
+		KeyCondition(string, map[string]types.AttributeValue) string
+	}
+	for _, opt := range opts {
+		if kc, ok := opt.(interface {
+			KeyCondition(string, map[string]types.AttributeValue) string
+		}); ok {
+			// DynamoDB accepts single condition on the sort key
+			if keyCondition != nil {
+				return nil, nil, errUnsupportedOpt.New(nil, "multiple key conditions, use single ddb.SortKey")
+			}
+			keyCondition = kc
+		}
+	}
+
+	// Key condition replaces the sort key of the pattern
+	if keyCondition != nil {
+		delete(gen, db.codec.skSuffix)
+		isSuffix = false
+	}
+
+	values := exprOf(gen)
 	expr := db.codec.pkPrefix + " = :__" + db.codec.pkPrefix + "__"
-	if isSuffix {
+	switch {
+	case keyCondition != nil:
+		expr = expr + " and " + keyCondition.KeyCondition(db.codec.skSuffix, values)
+	case isSuffix:
 		expr = expr + " and begins_with(" + db.codec.skSuffix + ", :__" + db.codec.skSuffix + "__)"
 	}
 
-	q := db.reqQuery(gen, expr, opts)
+	q, err := db.reqQuery(values, expr, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	val, err := db.service.Query(ctx, q)
 	if err != nil {
-		return nil, nil, errServiceIO.New(err)
+		return nil, nil, errServiceIO.New(errService(err))
 	}
 
 	seq := make([]T, val.Count)
@@ -67,52 +111,166 @@ func (db *Storage[T]) match(ctx context.Context, gen map[string]types.AttributeV
 		seq[i] = obj
 	}
 
-	return seq, lastKeyToCursor(db.codec, val), nil
+	return seq, lastKeyToCursor(db.codec, val.LastEvaluatedKey), nil
 }
 
 func (db *Storage[T]) reqQuery(
-	gen map[string]types.AttributeValue,
+	values map[string]types.AttributeValue,
 	expr string,
 	opts []interface{ MatcherOpt(T) },
-) *dynamodb.QueryInput {
+) (*dynamodb.QueryInput, error) {
 	var (
 		limit             *int32                          = nil
+		scanIndexForward  *bool                           = nil
+		consistentRead    *bool                           = nil
 		exclusiveStartKey map[string]types.AttributeValue = nil
 	)
 	for _, opt := range opts {
 		switch v := opt.(type) {
 		case interface{ Limit() int32 }:
 			limit = aws.Int32(v.Limit())
-		case dynamo.Thing:
-			prefix := v.HashKey()
-			suffix := v.SortKey()
-
-			if prefix != "" {
-				key := map[string]types.AttributeValue{}
-
-				key[db.codec.pkPrefix] = &types.AttributeValueMemberS{Value: string(prefix)}
-				if suffix != "" {
-					key[db.codec.skSuffix] = &types.AttributeValueMemberS{Value: string(suffix)}
-				} else {
-					key[db.codec.skSuffix] = &types.AttributeValueMemberS{Value: "_"}
-				}
-				exclusiveStartKey = key
+		case interface{ Reverse() bool }:
+			scanIndexForward = aws.Bool(!v.Reverse())
+		case interface{ ConsistentRead() bool }:
+			if db.index != "" && !db.localIndex {
+				return nil, errUnsupportedOpt.New(nil, "ConsistentRead with global secondary index, see ddb.WithLocalSecondaryIndex")
+			}
+			consistentRead = aws.Bool(v.ConsistentRead())
+		case interface{ SkipExpired() bool }:
+			if db.ttlAttribute == "" {
+				return nil, errUnsupportedOpt.New(nil, "SkipExpired without TTL attribute, see ddb.WithTTLAttribute")
 			}
+		case dynamo.Thing:
+			exclusiveStartKey = db.cursorToStartKey(v)
 		}
 	}
 
+	projection, names := db.maybeKeysOnly(opts)
+	names, filterExpression := db.maybeFilterExpression(names, values, opts)
+
 	req := &dynamodb.QueryInput{
 		KeyConditionExpression:    aws.String(expr),
-		ExpressionAttributeValues: exprOf(gen),
-		ProjectionExpression:      db.schema.Projection,
-		ExpressionAttributeNames:  db.schema.ExpectedAttributeNames,
+		ExpressionAttributeValues: values,
+		ProjectionExpression:      projection,
+		ExpressionAttributeNames:  names,
+		FilterExpression:          filterExpression,
 		TableName:                 awsString(db.table),
 		IndexName:                 awsString(db.index),
 		Limit:                     limit,
+		ScanIndexForward:          scanIndexForward,
+		ConsistentRead:            consistentRead,
 		ExclusiveStartKey:         exclusiveStartKey,
 	}
 
-	return req
+	return req, nil
+}
+
+// builds projection expression from options, KeysOnly projects hash and
+// sort key attributes instead of the schema.
+func (db *Storage[T]) maybeKeysOnly(opts []interface{ MatcherOpt(T) }) (*string, map[string]string) {
+	for _, opt := range opts {
+		if v, ok := opt.(interface{ KeysOnly() bool }); ok && v.KeysOnly() {
+			names := map[string]string{
+				"#__key_hash__": db.codec.pkPrefix,
+				"#__key_sort__": db.codec.skSuffix,
+			}
+			return aws.String("#__key_hash__, #__key_sort__"), names
+		}
+	}
+
+	return db.schema.Projection, db.schema.ExpectedAttributeNames
+}
+
+// builds filter expression from options, the expression attribute names
+// of projection are extended with names of filtered attributes.
+func (db *Storage[T]) maybeFilterExpression(
+	projection map[string]string,
+	values map[string]types.AttributeValue,
+	opts []interface{ MatcherOpt(T) },
+) (map[string]string, *string) {
+	var (
+		names            map[string]string = nil
+		filterExpression []string          = nil
+	)
+
+	// projection names are shared by all requests, filter requires a copy
+	copyNames := func() {
+		if names == nil {
+			names = make(map[string]string, len(projection))
+			for k, v := range projection {
+				names[k] = v
+			}
+		}
+	}
+
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case interface {
+			FilterExpression(map[string]string, map[string]types.AttributeValue) string
+		}:
+			copyNames()
+			if expr := v.FilterExpression(names, values); expr != "" {
+				filterExpression = append(filterExpression, expr)
+			}
+		case interface{ SkipExpired() bool }:
+			if v.SkipExpired() && db.ttlAttribute != "" {
+				copyNames()
+				filterExpression = append(filterExpression, db.ttlFilterExpression(names, values))
+			}
+		}
+	}
+
+	if len(names) == 0 {
+		names = projection
+	}
+
+	if len(filterExpression) == 0 {
+		return names, nil
+	}
+
+	return names, aws.String(strings.Join(filterExpression, " and "))
+}
+
+// builds exclusive start key from cursor
+func (db *Storage[T]) cursorToStartKey(cursor dynamo.Thing) map[string]types.AttributeValue {
+	if c, ok := cursor.(interface{ Cursor() dynamo.Thing }); ok {
+		cursor = c.Cursor()
+	}
+
+	// The cursor obtained from DynamoDB carries the complete key, including
+	// attributes of global secondary index.
+	if c, ok := cursor.(interface {
+		LastEvaluatedKey() map[string]types.AttributeValue
+	}); ok {
+		if key := c.LastEvaluatedKey(); len(key) != 0 {
+			return key
+		}
+	}
+
+	// The cursor decoded from opaque token (see dynamo.CursorCodec)
+	if c, ok := cursor.(interface{ CursorKey() json.RawMessage }); ok {
+		if key := decodeCursorKey(c.CursorKey()); len(key) != 0 {
+			return key
+		}
+	}
+
+	prefix := cursor.HashKey()
+	suffix := cursor.SortKey()
+
+	if prefix == "" {
+		return nil
+	}
+
+	key := map[string]types.AttributeValue{}
+
+	key[db.codec.pkPrefix] = &types.AttributeValueMemberS{Value: string(prefix)}
+	if suffix != "" {
+		key[db.codec.skSuffix] = &types.AttributeValueMemberS{Value: string(suffix)}
+	} else {
+		key[db.codec.skSuffix] = &types.AttributeValueMemberS{Value: "_"}
+	}
+
+	return key
 }
 
 func awsString(x string) *string {
@@ -138,19 +296,83 @@ func exprOf(gen map[string]types.AttributeValue) (val map[string]types.Attribute
 	return
 }
 
-type cursor struct{ hashKey, sortKey string }
+// cursor is the LastEvaluatedKey of DynamoDB, it carries table keys and
+// attributes of global secondary index.
+type cursor struct {
+	hashKey, sortKey string
+	lastEvaluatedKey map[string]types.AttributeValue
+}
 
 func (c cursor) HashKey() curie.IRI { return curie.IRI(c.hashKey) }
 func (c cursor) SortKey() curie.IRI { return curie.IRI(c.sortKey) }
 
-func lastKeyToCursor[T dynamo.Thing](codec *codec[T], val *dynamodb.QueryOutput) interface{ MatcherOpt(T) } {
-	if val.LastEvaluatedKey == nil {
+func (c cursor) LastEvaluatedKey() map[string]types.AttributeValue { return c.lastEvaluatedKey }
+
+// CursorKey serializes LastEvaluatedKey for dynamo.CursorCodec
+func (c cursor) CursorKey() json.RawMessage {
+	if len(c.lastEvaluatedKey) == 0 {
+		return nil
+	}
+
+	key := make(map[string]keyAttribute, len(c.lastEvaluatedKey))
+	for k, v := range c.lastEvaluatedKey {
+		switch v := v.(type) {
+		case *types.AttributeValueMemberS:
+			key[k] = keyAttribute{S: aws.String(v.Value)}
+		case *types.AttributeValueMemberN:
+			key[k] = keyAttribute{N: aws.String(v.Value)}
+		case *types.AttributeValueMemberB:
+			key[k] = keyAttribute{B: v.Value}
+		}
+	}
+
+	data, err := json.Marshal(key)
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
+
+// JSON representation of key attribute, keys are either string, number or binary
+type keyAttribute struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+	B []byte  `json:"B,omitempty"`
+}
+
+func decodeCursorKey(data json.RawMessage) map[string]types.AttributeValue {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var key map[string]keyAttribute
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil
+	}
+
+	gen := make(map[string]types.AttributeValue, len(key))
+	for k, v := range key {
+		switch {
+		case v.S != nil:
+			gen[k] = &types.AttributeValueMemberS{Value: *v.S}
+		case v.N != nil:
+			gen[k] = &types.AttributeValueMemberN{Value: *v.N}
+		case v.B != nil:
+			gen[k] = &types.AttributeValueMemberB{Value: v.B}
+		}
+	}
+
+	return gen
+}
+
+func lastKeyToCursor[T dynamo.Thing](codec *codec[T], key map[string]types.AttributeValue) interface{ MatcherOpt(T) } {
+	if key == nil {
 		return nil
 	}
 
 	var hkey, skey string
 
-	key := val.LastEvaluatedKey
 	prefix, isPrefix := key[codec.pkPrefix]
 	if isPrefix {
 		switch v := prefix.(type) {
@@ -167,5 +389,5 @@ func lastKeyToCursor[T dynamo.Thing](codec *codec[T], val *dynamodb.QueryOutput)
 		}
 	}
 
-	return dynamo.Cursor[T](&cursor{hashKey: hkey, sortKey: skey})
+	return dynamo.Cursor[T](&cursor{hashKey: hkey, sortKey: skey, lastEvaluatedKey: key})
 }
@@ -0,0 +1,316 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/it/v2"
+)
+
+type queryRecorder struct {
+	DynamoDB
+	req     *dynamodb.QueryInput
+	lastKey map[string]types.AttributeValue
+}
+
+func (mock *queryRecorder) Query(ctx context.Context, input *dynamodb.QueryInput, opts ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error) {
+	mock.req = input
+	return &dynamodb.QueryOutput{LastEvaluatedKey: mock.lastKey}, nil
+}
+
+func TestMatchReverse(t *testing.T) {
+	key := tKeyCondition{Prefix: "a"}
+
+	t.Run("Default", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		_, _, err := db.Match(context.Background(), key)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.True(mock.req.ScanIndexForward == nil),
+		)
+	})
+
+	t.Run("Reverse", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		_, _, err := db.Match(context.Background(), key, dynamo.Reverse[tKeyCondition]())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*mock.req.ScanIndexForward, false),
+		)
+	})
+}
+
+type tFilter struct {
+	Prefix curie.IRI `dynamodbav:"prefix,omitempty"`
+	Suffix curie.IRI `dynamodbav:"suffix,omitempty"`
+	Name   string    `dynamodbav:"anothername,omitempty"`
+	Age    int       `dynamodbav:"age,omitempty"`
+}
+
+func (x tFilter) HashKey() curie.IRI { return x.Prefix }
+func (x tFilter) SortKey() curie.IRI { return x.Suffix }
+
+func TestMatchFilter(t *testing.T) {
+	name := ClauseFor[tFilter, string]("Name")
+	age := ClauseFor[tFilter, int]("Age")
+	key := tFilter{Prefix: "a"}
+
+	t.Run("Filter", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tFilter]("test", WithDynamoDB(mock)))
+
+		_, _, err := db.Match(context.Background(), key,
+			Filter(name.Eq("x"), age.Gt(18)),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*mock.req.FilterExpression, "(#__c_anothername__ = :__c_anothername__) and (#__c_age__ > :__c_age__)"),
+			it.Map(mock.req.ExpressionAttributeNames).Have("#__c_anothername__", "anothername"),
+			it.Map(mock.req.ExpressionAttributeNames).Have("#__c_age__", "age"),
+			it.Map(mock.req.ExpressionAttributeValues).Have(":__c_anothername__", &types.AttributeValueMemberS{Value: "x"}),
+			it.Map(mock.req.ExpressionAttributeValues).Have(":__c_age__", &types.AttributeValueMemberN{Value: "18"}),
+			it.Map(mock.req.ExpressionAttributeValues).Have(":__prefix__", &types.AttributeValueMemberS{Value: "a"}),
+		)
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tFilter]("test", WithDynamoDB(mock)))
+
+		_, _, err := db.Match(context.Background(), key,
+			Filter(age.Gt(18), age.Lt(60)),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*mock.req.FilterExpression, "(#__c_age__ > :__c_age__) and (#__c_age__ < :__c_age_1__)"),
+			it.Map(mock.req.ExpressionAttributeValues).Have(":__c_age__", &types.AttributeValueMemberN{Value: "18"}),
+			it.Map(mock.req.ExpressionAttributeValues).Have(":__c_age_1__", &types.AttributeValueMemberN{Value: "60"}),
+		)
+	})
+
+	t.Run("OneOf", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tFilter]("test", WithDynamoDB(mock)))
+
+		_, _, err := db.Match(context.Background(), key,
+			Filter(OneOf(name.NotExists(), name.Eq("x")), age.Gt(18)),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*mock.req.FilterExpression, "((attribute_not_exists(#__c_anothername__)) or (#__c_anothername__ = :__c_anothername__)) and (#__c_age__ > :__c_age__)"),
+		)
+	})
+
+	t.Run("StrictType", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tFilter]("test", WithDynamoDB(mock), WithStrictType(true)))
+
+		_, _, err := db.Match(context.Background(), key, Filter(name.Eq("x")))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Map(mock.req.ExpressionAttributeNames).Have("#__c_anothername__", "anothername"),
+			it.Map(mock.req.ExpressionAttributeNames).Have("#__anothername__", "anothername"),
+		).ShouldNot(
+			it.Map(db.schema.ExpectedAttributeNames).Have("#__c_anothername__", "anothername"),
+		)
+	})
+}
+
+func TestMatchKeysOnly(t *testing.T) {
+	name := ClauseFor[tFilter, string]("Name")
+	key := tFilter{Prefix: "a"}
+
+	t.Run("Query", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tFilter]("test", WithDynamoDB(mock), WithStrictType(true)))
+
+		_, _, err := db.Match(context.Background(), key, dynamo.KeysOnly[tFilter]())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*mock.req.ProjectionExpression, "#__key_hash__, #__key_sort__"),
+			it.Equiv(mock.req.ExpressionAttributeNames, map[string]string{
+				"#__key_hash__": "prefix",
+				"#__key_sort__": "suffix",
+			}),
+		)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tFilter]("test", WithDynamoDB(mock), WithStrictType(true)))
+
+		_, _, err := db.Match(context.Background(), key,
+			dynamo.KeysOnly[tFilter](),
+			Filter(name.Eq("x")),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*mock.req.ProjectionExpression, "#__key_hash__, #__key_sort__"),
+			it.Map(mock.req.ExpressionAttributeNames).Have("#__c_anothername__", "anothername"),
+		).ShouldNot(
+			it.Map(mock.req.ExpressionAttributeNames).Have("#__anothername__", "anothername"),
+		)
+	})
+}
+
+func TestMatchConsistentRead(t *testing.T) {
+	key := tKeyCondition{Prefix: "a"}
+
+	t.Run("Table", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		_, _, err := db.Match(context.Background(), key, dynamo.ConsistentRead[tKeyCondition]())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*mock.req.ConsistentRead, true),
+		)
+	})
+
+	t.Run("GlobalSecondaryIndex", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tKeyCondition]("test",
+			WithDynamoDB(mock),
+			WithGlobalSecondaryIndex("index"),
+		))
+
+		_, _, err := db.Match(context.Background(), key, dynamo.ConsistentRead[tKeyCondition]())
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		).Should(
+			it.True(mock.req == nil),
+		)
+	})
+
+	t.Run("LocalSecondaryIndex", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tKeyCondition]("test",
+			WithDynamoDB(mock),
+			WithLocalSecondaryIndex("index"),
+		))
+
+		_, _, err := db.Match(context.Background(), key, dynamo.ConsistentRead[tKeyCondition]())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*mock.req.IndexName, "index"),
+			it.Equal(*mock.req.ConsistentRead, true),
+		)
+	})
+}
+
+func TestMatchCursor(t *testing.T) {
+	key := tKeyCondition{Prefix: "a"}
+
+	t.Run("Table", func(t *testing.T) {
+		mock := &queryRecorder{
+			lastKey: map[string]types.AttributeValue{
+				"prefix": &types.AttributeValueMemberS{Value: "a"},
+				"suffix": &types.AttributeValueMemberS{Value: "b"},
+			},
+		}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		_, cur, err := db.Match(context.Background(), key)
+		it.Then(t).Should(it.Nil(err))
+
+		_, _, err = db.Match(context.Background(), key, cur)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(mock.req.ExclusiveStartKey, mock.lastKey),
+		)
+	})
+
+	t.Run("GlobalSecondaryIndex", func(t *testing.T) {
+		mock := &queryRecorder{
+			lastKey: map[string]types.AttributeValue{
+				"prefix": &types.AttributeValueMemberS{Value: "a"},
+				"suffix": &types.AttributeValueMemberS{Value: "b"},
+				"gsi_pk": &types.AttributeValueMemberS{Value: "c"},
+				"gsi_sk": &types.AttributeValueMemberS{Value: "d"},
+			},
+		}
+		db := Must(New[tKeyCondition]("test",
+			WithDynamoDB(mock),
+			WithGlobalSecondaryIndex("index"),
+		))
+
+		_, cur, err := db.Match(context.Background(), key)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(cur.(dynamo.Thing).HashKey(), "a"),
+			it.Equal(cur.(dynamo.Thing).SortKey(), "b"),
+		)
+
+		_, _, err = db.Match(context.Background(), key, cur)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(mock.req.ExclusiveStartKey, mock.lastKey),
+		)
+	})
+
+	t.Run("Token", func(t *testing.T) {
+		mock := &queryRecorder{
+			lastKey: map[string]types.AttributeValue{
+				"prefix": &types.AttributeValueMemberS{Value: "a"},
+				"suffix": &types.AttributeValueMemberS{Value: "b"},
+				"gsi_pk": &types.AttributeValueMemberS{Value: "c"},
+				"gsi_sk": &types.AttributeValueMemberN{Value: "10"},
+			},
+		}
+		db := Must(New[tKeyCondition]("test",
+			WithDynamoDB(mock),
+			WithGlobalSecondaryIndex("index"),
+		))
+		codec, err := dynamo.NewCursorCodec[tKeyCondition](
+			dynamo.WithCursorSigningKey([]byte("secret")),
+		)
+		it.Then(t).Should(it.Nil(err))
+
+		_, cur, err := db.Match(context.Background(), key)
+		it.Then(t).Should(it.Nil(err))
+
+		token, err := codec.Encode(cur)
+		it.Then(t).Should(it.Nil(err))
+
+		next, err := codec.Decode(token)
+		it.Then(t).Should(it.Nil(err))
+
+		_, _, err = db.Match(context.Background(), key, next)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(mock.req.ExclusiveStartKey, mock.lastKey),
+		)
+	})
+
+	t.Run("Thing", func(t *testing.T) {
+		mock := &queryRecorder{}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		_, _, err := db.Match(context.Background(), key,
+			dynamo.Cursor[tKeyCondition](tKeyCondition{Prefix: "a", Suffix: "b"}),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(mock.req.ExclusiveStartKey, map[string]types.AttributeValue{
+				"prefix": &types.AttributeValueMemberS{Value: "a"},
+				"suffix": &types.AttributeValueMemberS{Value: "b"},
+			}),
+		)
+	})
+}
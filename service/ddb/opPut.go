@@ -10,7 +10,6 @@ package ddb
 
 import (
 	"context"
-	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -19,36 +18,70 @@ import (
 
 // Put writes entity
 func (db *Storage[T]) Put(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) error {
+	req, err := db.reqPut(entity, opts)
+	if err != nil {
+		return err
+	}
+
+	val, err := db.service.PutItem(ctx, req)
+	if err != nil {
+		if recoverConditionalCheckFailedException(err) {
+			return db.errConditionCheckFailed(err, entity, opts)
+		}
+		return errServiceIO.New(errService(err))
+	}
+
+	if req.ReturnValues == types.ReturnValueAllOld {
+		old := db.undefined
+		if len(val.Attributes) != 0 {
+			old, err = db.decodeReturnValues(req.ReturnValues, nil, val.Attributes)
+			if err != nil {
+				return err
+			}
+		}
+		returnOldTo(opts, old)
+	}
+
+	return nil
+}
+
+func (db *Storage[T]) reqPut(entity T, opts []interface{ WriterOpt(T) }) (*dynamodb.PutItemInput, error) {
 	gen, err := db.codec.Encode(entity)
 	if err != nil {
-		return errInvalidEntity.New(err)
+		return nil, errInvalidEntity.New(err)
+	}
+
+	if err := db.maybeTTL(gen, opts); err != nil {
+		return nil, err
+	}
+
+	rv := returnValuesOf(opts, types.ReturnValueNone)
+	if rv != types.ReturnValueNone && rv != types.ReturnValueAllOld {
+		return nil, errUnsupportedOpt.New(nil, "ReturnValues "+string(rv)+" with Put, use ReturnOld")
 	}
 
 	req := &dynamodb.PutItemInput{
-		Item:      gen,
-		TableName: aws.String(db.table),
+		Item:                                gen,
+		TableName:                           aws.String(db.table),
+		ReturnValues:                        rv,
+		ReturnValuesOnConditionCheckFailure: returnOnConditionCheckFailureOf(opts),
 	}
 
 	names, values := maybeConditionExpression(&req.ConditionExpression, opts)
 	req.ExpressionAttributeValues = values
 	req.ExpressionAttributeNames = names
 
-	_, err = db.service.PutItem(ctx, req)
-	if err != nil {
-		if recoverConditionalCheckFailedException(err) {
-			return errPreConditionFailed(err, entity,
-				strings.Contains(*req.ConditionExpression, "attribute_not_exists") || strings.Contains(*req.ConditionExpression, "="),
-				strings.Contains(*req.ConditionExpression, "attribute_exists") || strings.Contains(*req.ConditionExpression, "<>"),
-			)
-		}
-		return errServiceIO.New(err)
-	}
-
-	return nil
+	return req, nil
 }
 
-// Put multiple items at once
+// Put multiple items at once. Items are split into chunks written concurrently,
+// unprocessed items are retried with exponential backoff. It returns items,
+// which are not written.
 func (db *Storage[T]) BatchPut(ctx context.Context, entities []T, opts ...interface{ WriterOpt(T) }) ([]T, error) {
+	if err := checkBatchOpts("BatchPut", opts); err != nil {
+		return nil, err
+	}
+
 	if len(entities) == 0 {
 		return nil, nil
 	}
@@ -62,24 +95,9 @@ func (db *Storage[T]) BatchPut(ctx context.Context, entities []T, opts ...interf
 		seq[i] = types.WriteRequest{PutRequest: &types.PutRequest{Item: gen}}
 	}
 
-	req := &dynamodb.BatchWriteItemInput{
-		RequestItems: map[string][]types.WriteRequest{
-			db.table: seq,
-		},
-	}
-
-	val, err := db.service.BatchWriteItem(ctx, req)
+	fails, err := db.batchWriteAll(ctx, seq)
 	if err != nil {
-		return nil, errServiceIO.New(err)
-	}
-
-	if len(val.UnprocessedItems) != 0 {
-		items := val.UnprocessedItems[db.table]
-		fails := make([]T, len(items))
-		for i, r := range items {
-			fails[i], _ = db.codec.Decode(r.PutRequest.Item)
-		}
-		return fails, errBatchPartialIO.New(nil)
+		return db.decodeWriteRequests(fails), err
 	}
 
 	return nil, nil
@@ -10,7 +10,6 @@ package ddb
 
 import (
 	"context"
-	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -19,41 +18,63 @@ import (
 
 // Remove discards the entity from the table
 func (db *Storage[T]) Remove(ctx context.Context, key T, opts ...interface{ WriterOpt(T) }) (T, error) {
-	gen, err := db.codec.EncodeKey(key)
+	req, err := db.reqRemove(key, opts)
 	if err != nil {
-		return db.undefined, errInvalidKey.New(err)
-	}
-
-	req := &dynamodb.DeleteItemInput{
-		Key:          gen,
-		TableName:    aws.String(db.table),
-		ReturnValues: "ALL_OLD",
+		return db.undefined, err
 	}
-	names, values := maybeConditionExpression(&req.ConditionExpression, opts)
-	req.ExpressionAttributeValues = values
-	req.ExpressionAttributeNames = names
 
 	val, err := db.service.DeleteItem(ctx, req)
 	if err != nil {
 		if recoverConditionalCheckFailedException(err) {
-			return db.undefined, errPreConditionFailed(err, key,
-				strings.Contains(*req.ConditionExpression, "attribute_not_exists") || strings.Contains(*req.ConditionExpression, "="),
-				strings.Contains(*req.ConditionExpression, "attribute_exists") || strings.Contains(*req.ConditionExpression, "<>"),
-			)
+			return db.undefined, db.errConditionCheckFailed(err, key, opts)
 		}
-		return db.undefined, errServiceIO.New(err)
+		return db.undefined, errServiceIO.New(errService(err))
 	}
 
-	obj, err := db.codec.Decode(val.Attributes)
+	obj, err := db.decodeReturnValues(req.ReturnValues, req.Key, val.Attributes)
 	if err != nil {
-		return db.undefined, errInvalidEntity.New(err)
+		return db.undefined, err
+	}
+
+	if req.ReturnValues == types.ReturnValueAllOld {
+		returnOldTo(opts, obj)
 	}
 
 	return obj, nil
 }
 
-// Remove multiple items at once
+func (db *Storage[T]) reqRemove(key T, opts []interface{ WriterOpt(T) }) (*dynamodb.DeleteItemInput, error) {
+	gen, err := db.codec.EncodeKey(key)
+	if err != nil {
+		return nil, errInvalidKey.New(err)
+	}
+
+	rv := returnValuesOf(opts, types.ReturnValueAllOld)
+	if rv != types.ReturnValueNone && rv != types.ReturnValueAllOld {
+		return nil, errUnsupportedOpt.New(nil, "ReturnValues "+string(rv)+" with Remove")
+	}
+
+	req := &dynamodb.DeleteItemInput{
+		Key:                                 gen,
+		TableName:                           aws.String(db.table),
+		ReturnValues:                        rv,
+		ReturnValuesOnConditionCheckFailure: returnOnConditionCheckFailureOf(opts),
+	}
+	names, values := maybeConditionExpression(&req.ConditionExpression, opts)
+	req.ExpressionAttributeValues = values
+	req.ExpressionAttributeNames = names
+
+	return req, nil
+}
+
+// Remove multiple items at once. Keys are split into chunks removed concurrently,
+// unprocessed keys are retried with exponential backoff. It returns keys,
+// which are not removed.
 func (db *Storage[T]) BatchRemove(ctx context.Context, keys []T, opts ...interface{ WriterOpt(T) }) ([]T, error) {
+	if err := checkBatchOpts("BatchRemove", opts); err != nil {
+		return nil, err
+	}
+
 	if len(keys) == 0 {
 		return nil, nil
 	}
@@ -67,24 +88,9 @@ func (db *Storage[T]) BatchRemove(ctx context.Context, keys []T, opts ...interfa
 		seq[i] = types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: gen}}
 	}
 
-	req := &dynamodb.BatchWriteItemInput{
-		RequestItems: map[string][]types.WriteRequest{
-			db.table: seq,
-		},
-	}
-
-	val, err := db.service.BatchWriteItem(ctx, req)
+	fails, err := db.batchWriteAll(ctx, seq)
 	if err != nil {
-		return nil, errServiceIO.New(err)
-	}
-
-	if len(val.UnprocessedItems) != 0 {
-		items := val.UnprocessedItems[db.table]
-		fails := make([]T, len(items))
-		for i, r := range items {
-			fails[i], _ = db.codec.Decode(r.PutRequest.Item)
-		}
-		return fails, errBatchPartialIO.New(nil)
+		return db.decodeWriteRequests(fails), err
 	}
 
 	return nil, nil
@@ -0,0 +1,222 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/iterator"
+)
+
+// Segment option for Scan, it reads the segment of the table, allowing
+// multiple workers to scan the table in parallel.
+//
+//	db.Scan(ctx, ddb.Segment[T](0, 4))
+func Segment[T dynamo.Thing](segment, totalSegments int32) interface{ MatcherOpt(T) } {
+	return segmentOpt[T]{segment: segment, totalSegments: totalSegments}
+}
+
+type segmentOpt[T dynamo.Thing] struct{ segment, totalSegments int32 }
+
+func (segmentOpt[T]) MatcherOpt(T) {}
+
+func (s segmentOpt[T]) Segment() (int32, int32) { return s.segment, s.totalSegments }
+
+// Scan reads all elements of the table. The scan is paginated using
+// Limit and Cursor options, supports filter expressions and segments.
+func (db *Storage[T]) Scan(ctx context.Context, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	scanner, ok := db.service.(DynamoDBScan)
+	if !ok {
+		return nil, nil, errUnsupportedAPI.New(nil, "Scan")
+	}
+
+	req, err := db.reqScan(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	val, err := scanner.Scan(ctx, req)
+	if err != nil {
+		return nil, nil, errServiceIO.New(errService(err))
+	}
+
+	seq := make([]T, val.Count)
+	for i := 0; i < int(val.Count); i++ {
+		obj, err := db.codec.Decode(val.Items[i])
+		if err != nil {
+			return nil, nil, errInvalidEntity.New(err)
+		}
+		seq[i] = obj
+	}
+
+	return seq, lastKeyToCursor(db.codec, val.LastEvaluatedKey), nil
+}
+
+// ScanSeq lazily iterates over all elements of the table, it follows
+// LastEvaluatedKey until the table or MaxItems is exhausted.
+func (db *Storage[T]) ScanSeq(ctx context.Context, opts ...interface{ MatcherOpt(T) }) iter.Seq2[T, error] {
+	return iterator.Seq(ctx, db.Scan, opts)
+}
+
+// ScanParallel reads all elements of the table, the work is split across
+// the given number of segments, each segment is scanned concurrently until
+// exhausted. The results of segments are merged. Cursor is not supported,
+// the first failure cancels other segments.
+func (db *Storage[T]) ScanParallel(ctx context.Context, totalSegments int32, opts ...interface{ MatcherOpt(T) }) ([]T, error) {
+	if totalSegments < 1 {
+		totalSegments = 1
+	}
+
+	// segments are scanned from the beginning, the cursor is not defined for all
+	for _, opt := range opts {
+		if _, ok := opt.(dynamo.Thing); ok {
+			return nil, errUnsupportedOpt.New(nil, "Cursor with ScanParallel")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	segs := make([][]T, totalSegments)
+	errs := make([]error, totalSegments)
+
+	for i := int32(0); i < totalSegments; i++ {
+		wg.Add(1)
+		go func(segment int32) {
+			defer wg.Done()
+			segs[segment], errs[segment] = db.scanSegment(ctx, segment, totalSegments, opts)
+			if errs[segment] != nil {
+				cancel()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := firstErrorOf(errs); err != nil {
+		return nil, err
+	}
+
+	seq := make([]T, 0)
+	for _, seg := range segs {
+		seq = append(seq, seg...)
+	}
+
+	return seq, nil
+}
+
+// the failure of segment cancels other ones, the error of the segment is
+// returned instead of cancellations caused by it.
+func firstErrorOf(errs []error) error {
+	var first error
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			continue
+		case !errors.Is(err, context.Canceled):
+			return err
+		case first == nil:
+			first = err
+		}
+	}
+	return first
+}
+
+func (db *Storage[T]) scanSegment(ctx context.Context, segment, totalSegments int32, opts []interface{ MatcherOpt(T) }) ([]T, error) {
+	var (
+		seq    = make([]T, 0)
+		cursor interface{ MatcherOpt(T) }
+	)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		opt := make([]interface{ MatcherOpt(T) }, 0, len(opts)+2)
+		opt = append(opt, opts...)
+		opt = append(opt, Segment[T](segment, totalSegments))
+		if cursor != nil {
+			opt = append(opt, cursor)
+		}
+
+		page, next, err := db.Scan(ctx, opt...)
+		if err != nil {
+			return nil, err
+		}
+		seq = append(seq, page...)
+
+		if next == nil {
+			return seq, nil
+		}
+		cursor = next
+	}
+}
+
+func (db *Storage[T]) reqScan(opts []interface{ MatcherOpt(T) }) (*dynamodb.ScanInput, error) {
+	var (
+		limit             *int32                          = nil
+		consistentRead    *bool                           = nil
+		segment           *int32                          = nil
+		totalSegments     *int32                          = nil
+		exclusiveStartKey map[string]types.AttributeValue = nil
+	)
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case interface{ Limit() int32 }:
+			limit = aws.Int32(v.Limit())
+		case interface{ Segment() (int32, int32) }:
+			seg, total := v.Segment()
+			segment, totalSegments = aws.Int32(seg), aws.Int32(total)
+		case interface{ ConsistentRead() bool }:
+			if db.index != "" && !db.localIndex {
+				return nil, errUnsupportedOpt.New(nil, "ConsistentRead with global secondary index, see ddb.WithLocalSecondaryIndex")
+			}
+			consistentRead = aws.Bool(v.ConsistentRead())
+		case interface{ SkipExpired() bool }:
+			if db.ttlAttribute == "" {
+				return nil, errUnsupportedOpt.New(nil, "SkipExpired without TTL attribute, see ddb.WithTTLAttribute")
+			}
+		case dynamo.Thing:
+			exclusiveStartKey = db.cursorToStartKey(v)
+		}
+	}
+
+	values := map[string]types.AttributeValue{}
+	projection, names := db.maybeKeysOnly(opts)
+	names, filterExpression := db.maybeFilterExpression(names, values, opts)
+
+	// Unfortunately empty maps are not accepted by DynamoDB
+	if len(values) == 0 {
+		values = nil
+	}
+
+	req := &dynamodb.ScanInput{
+		ExpressionAttributeValues: values,
+		ProjectionExpression:      projection,
+		ExpressionAttributeNames:  names,
+		FilterExpression:          filterExpression,
+		TableName:                 awsString(db.table),
+		IndexName:                 awsString(db.index),
+		Limit:                     limit,
+		Segment:                   segment,
+		TotalSegments:             totalSegments,
+		ConsistentRead:            consistentRead,
+		ExclusiveStartKey:         exclusiveStartKey,
+	}
+
+	return req, nil
+}
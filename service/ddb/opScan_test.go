@@ -0,0 +1,278 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/it/v2"
+)
+
+// scanner serves items of segments page by page
+type scanner struct {
+	DynamoDB
+	sync.Mutex
+	segments [][]tKeyCondition
+	requests []*dynamodb.ScanInput
+	fail     bool
+	// the segment fails, other ones wait for cancellation
+	failSegment int32
+}
+
+func (mock *scanner) Scan(ctx context.Context, input *dynamodb.ScanInput, opts ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error) {
+	mock.Lock()
+	mock.requests = append(mock.requests, input)
+	mock.Unlock()
+
+	if mock.fail {
+		if aws.ToInt32(input.Segment) != mock.failSegment {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}
+		return nil, errors.New("fail")
+	}
+
+	seg := mock.segments[aws.ToInt32(input.Segment)]
+	pos := 0
+	if input.ExclusiveStartKey != nil {
+		pos, _ = strconv.Atoi(input.ExclusiveStartKey["suffix"].(*types.AttributeValueMemberS).Value)
+		pos++
+	}
+
+	end := len(seg)
+	if input.Limit != nil {
+		end = min(pos+int(*input.Limit), len(seg))
+	}
+
+	out := &dynamodb.ScanOutput{}
+	for _, x := range seg[pos:end] {
+		out.Items = append(out.Items, map[string]types.AttributeValue{
+			"prefix": &types.AttributeValueMemberS{Value: string(x.Prefix)},
+			"suffix": &types.AttributeValueMemberS{Value: string(x.Suffix)},
+		})
+	}
+	out.Count = int32(len(out.Items))
+
+	if end < len(seg) {
+		out.LastEvaluatedKey = map[string]types.AttributeValue{
+			"prefix": &types.AttributeValueMemberS{Value: "seg"},
+			"suffix": &types.AttributeValueMemberS{Value: strconv.Itoa(end - 1)},
+		}
+	}
+
+	return out, nil
+}
+
+func segmentOf(n int, prefix string) []tKeyCondition {
+	seq := make([]tKeyCondition, n)
+	for i := 0; i < n; i++ {
+		seq[i] = tKeyCondition{Prefix: curie.IRI(prefix), Suffix: curie.IRI(strconv.Itoa(i))}
+	}
+	return seq
+}
+
+func TestScan(t *testing.T) {
+	name := ClauseFor[tFilter, string]("Name")
+
+	t.Run("Page", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(5, "a")}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		seq, cursor, err := db.Scan(context.Background(), dynamo.Limit[tKeyCondition](2))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Seq(seq).Equal(segmentOf(2, "a")...),
+			it.Equal(cursor.(dynamo.Thing).SortKey(), "1"),
+		)
+
+		seq, cursor, err = db.Scan(context.Background(), dynamo.Limit[tKeyCondition](5), cursor)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Seq(seq).Equal(segmentOf(5, "a")[2:]...),
+			it.True(cursor == nil),
+		)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(1, "a")}}
+		db := Must(New[tFilter]("test", WithDynamoDB(mock)))
+
+		_, _, err := db.Scan(context.Background(), Filter(name.Eq("x")))
+		req := mock.requests[0]
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(*req.FilterExpression, "(#__c_anothername__ = :__c_anothername__)"),
+			it.Map(req.ExpressionAttributeNames).Have("#__c_anothername__", "anothername"),
+			it.Map(req.ExpressionAttributeValues).Have(":__c_anothername__", &types.AttributeValueMemberS{Value: "x"}),
+		)
+	})
+
+	t.Run("Segment", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(1, "a"), segmentOf(1, "b")}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		seq, _, err := db.Scan(context.Background(), Segment[tKeyCondition](1, 2))
+		req := mock.requests[0]
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Seq(seq).Equal(segmentOf(1, "b")...),
+			it.Equal(*req.Segment, 1),
+			it.Equal(*req.TotalSegments, 2),
+		)
+	})
+
+	t.Run("Parallel", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(5, "a"), segmentOf(3, "b"), segmentOf(0, "c")}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		seq, err := db.ScanParallel(context.Background(), 3, dynamo.Limit[tKeyCondition](2))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Seq(seq).Equal(append(segmentOf(5, "a"), segmentOf(3, "b")...)...),
+			it.Equal(len(mock.requests), 6),
+		)
+	})
+
+	t.Run("ParallelFailure", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(5, "a"), segmentOf(3, "b")}, fail: true}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		seq, err := db.ScanParallel(context.Background(), 2)
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		).Should(
+			it.Seq(seq).BeEmpty(),
+		)
+	})
+
+	t.Run("ParallelFailureCause", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(5, "a"), segmentOf(3, "b"), segmentOf(1, "c")}, fail: true, failSegment: 2}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		_, err := db.ScanParallel(context.Background(), 3)
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+			it.True(errors.Is(err, context.Canceled)),
+		)
+	})
+
+	t.Run("ParallelCursor", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(5, "a")}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		_, err := db.ScanParallel(context.Background(), 1,
+			dynamo.Cursor[tKeyCondition](tKeyCondition{Prefix: "seg", Suffix: "1"}),
+		)
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		).Should(
+			it.Equal(len(mock.requests), 0),
+		)
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(&batcher{})))
+
+		_, _, err := db.Scan(context.Background())
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		)
+	})
+}
+
+func TestScanSeq(t *testing.T) {
+	t.Run("All", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(10, "a")}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		seq := []tKeyCondition{}
+		for x, err := range db.ScanSeq(context.Background(), dynamo.Limit[tKeyCondition](3)) {
+			it.Then(t).Should(it.Nil(err))
+			seq = append(seq, x)
+		}
+
+		it.Then(t).Should(
+			it.Seq(seq).Equal(segmentOf(10, "a")...),
+			it.Equal(len(mock.requests), 4),
+		)
+	})
+
+	t.Run("MaxItems", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(10, "a")}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		seq := []tKeyCondition{}
+		for x, err := range db.ScanSeq(context.Background(),
+			dynamo.Limit[tKeyCondition](3),
+			dynamo.MaxItems[tKeyCondition](5),
+		) {
+			it.Then(t).Should(it.Nil(err))
+			seq = append(seq, x)
+		}
+
+		it.Then(t).Should(
+			it.Seq(seq).Equal(segmentOf(5, "a")...),
+			it.Equal(len(mock.requests), 2),
+		)
+	})
+
+	t.Run("Break", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(10, "a")}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		for range db.ScanSeq(context.Background(), dynamo.Limit[tKeyCondition](3)) {
+			break
+		}
+
+		it.Then(t).Should(
+			it.Equal(len(mock.requests), 1),
+		)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(10, "a")}}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		var err error
+		for _, e := range db.ScanSeq(ctx) {
+			err = e
+		}
+
+		it.Then(t).Should(
+			it.Equiv(err, context.Canceled),
+			it.Equal(len(mock.requests), 0),
+		)
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		mock := &scanner{segments: [][]tKeyCondition{segmentOf(10, "a")}, fail: true}
+		db := Must(New[tKeyCondition]("test", WithDynamoDB(mock)))
+
+		var err error
+		for _, e := range db.ScanSeq(context.Background()) {
+			err = e
+		}
+
+		it.Then(t).ShouldNot(
+			it.Nil(err),
+		)
+	})
+}
@@ -0,0 +1,405 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3"
+)
+
+// Max number of operations in TransactWriteItems and TransactGetItems request
+const transactSize = 100
+
+// TxWrite is a write operation of the transaction, use TxPut, TxUpdate,
+// TxUpdateWith, TxRemove and TxCheck to build it.
+type TxWrite struct {
+	service DynamoDB
+	thing   dynamo.Thing
+	item    types.TransactWriteItem
+	cond    []condition
+	err     error
+}
+
+// TxPut writes entity within the transaction
+func TxPut[T dynamo.Thing](db *Storage[T], entity T, opts ...interface{ WriterOpt(T) }) TxWrite {
+	req, err := db.reqPut(entity, opts)
+	if err != nil {
+		return TxWrite{err: err}
+	}
+
+	return TxWrite{
+		service: db.service,
+		thing:   entity,
+		cond:    conditionsOf(opts),
+		item: types.TransactWriteItem{
+			Put: &types.Put{
+				Item:                      req.Item,
+				TableName:                 req.TableName,
+				ConditionExpression:       req.ConditionExpression,
+				ExpressionAttributeNames:  req.ExpressionAttributeNames,
+				ExpressionAttributeValues: req.ExpressionAttributeValues,
+			},
+		},
+	}
+}
+
+// TxUpdate applies a partial patch to entity within the transaction
+func TxUpdate[T dynamo.Thing](db *Storage[T], entity T, opts ...interface{ WriterOpt(T) }) TxWrite {
+	req, err := db.reqUpdate(entity, opts)
+	if err != nil {
+		return TxWrite{err: err}
+	}
+
+	return txUpdate(db.service, entity, req, conditionsOf(opts))
+}
+
+// TxUpdateWith applies update expression to entity within the transaction
+func TxUpdateWith[T dynamo.Thing](db *Storage[T], expression UpdateItemExpression[T], opts ...interface{ WriterOpt(T) }) TxWrite {
+	req, err := db.reqUpdateWith(expression, opts)
+	if err != nil {
+		return TxWrite{err: err}
+	}
+
+	return txUpdate(db.service, expression.entity, req, conditionsOf(opts))
+}
+
+func txUpdate(service DynamoDB, thing dynamo.Thing, req *dynamodb.UpdateItemInput, cond []condition) TxWrite {
+	return TxWrite{
+		service: service,
+		thing:   thing,
+		cond:    cond,
+		item: types.TransactWriteItem{
+			Update: &types.Update{
+				Key:                       req.Key,
+				TableName:                 req.TableName,
+				UpdateExpression:          req.UpdateExpression,
+				ConditionExpression:       req.ConditionExpression,
+				ExpressionAttributeNames:  nilIfEmpty(req.ExpressionAttributeNames),
+				ExpressionAttributeValues: nilIfEmpty(req.ExpressionAttributeValues),
+			},
+		},
+	}
+}
+
+// TxRemove discards the entity within the transaction
+func TxRemove[T dynamo.Thing](db *Storage[T], key T, opts ...interface{ WriterOpt(T) }) TxWrite {
+	req, err := db.reqRemove(key, opts)
+	if err != nil {
+		return TxWrite{err: err}
+	}
+
+	return TxWrite{
+		service: db.service,
+		thing:   key,
+		cond:    conditionsOf(opts),
+		item: types.TransactWriteItem{
+			Delete: &types.Delete{
+				Key:                       req.Key,
+				TableName:                 req.TableName,
+				ConditionExpression:       req.ConditionExpression,
+				ExpressionAttributeNames:  req.ExpressionAttributeNames,
+				ExpressionAttributeValues: req.ExpressionAttributeValues,
+			},
+		},
+	}
+}
+
+// TxCheck asserts conditions on the entity within the transaction,
+// the entity is not modified.
+func TxCheck[T dynamo.Thing](db *Storage[T], key T, opts ...interface{ WriterOpt(T) }) TxWrite {
+	gen, err := db.codec.EncodeKey(key)
+	if err != nil {
+		return TxWrite{err: errInvalidKey.New(err)}
+	}
+
+	check := &types.ConditionCheck{
+		Key:       gen,
+		TableName: &db.table,
+	}
+	names, values := maybeConditionExpression(&check.ConditionExpression, opts)
+	check.ExpressionAttributeNames = names
+	check.ExpressionAttributeValues = values
+
+	if check.ConditionExpression == nil {
+		return TxWrite{err: errUndefinedCondition.New(nil)}
+	}
+
+	return TxWrite{
+		service: db.service,
+		thing:   key,
+		cond:    conditionsOf(opts),
+		item:    types.TransactWriteItem{ConditionCheck: check},
+	}
+}
+
+// TransactWrite commits write operations atomically, operations might target
+// multiple storages (tables and types) accessible by same DynamoDB client.
+// Either all operations succeed or none of them is applied.
+//
+//	ddb.TransactWrite(ctx,
+//	  ddb.TxPut(authors, author, ifName.NotExists()),
+//	  ddb.TxUpdate(articles, article),
+//	)
+//
+// The transaction cancelled due to failed condition returns error, which
+// acts as PreConditionFailed. The error unwraps to per-item errors.
+//
+// All storages must share the DynamoDB client, the transaction is limited
+// to 100 operations.
+func TransactWrite(ctx context.Context, ops ...TxWrite) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	if len(ops) > transactSize {
+		return errTxTooLarge.New(nil, len(ops))
+	}
+
+	seq := make([]types.TransactWriteItem, len(ops))
+	for i, op := range ops {
+		if op.err != nil {
+			return op.err
+		}
+		if i > 0 && !sameService(op.service, ops[0].service) {
+			return errTxMixedServices.New(nil)
+		}
+		seq[i] = op.item
+	}
+
+	service, ok := ops[0].service.(DynamoDBTransactWrite)
+	if !ok {
+		return errUnsupportedAPI.New(nil, "TransactWriteItems")
+	}
+
+	req := &dynamodb.TransactWriteItemsInput{TransactItems: seq}
+
+	_, err := service.TransactWriteItems(ctx, req)
+	if err != nil {
+		if reasons, ok := recoverTransactionCanceledException(err); ok {
+			return errTransactionCanceled(err, ops, reasons)
+		}
+		return errServiceIO.New(errService(err))
+	}
+
+	return nil
+}
+
+// TxRead is a read operation of the transaction, use TxGet to build it.
+type TxRead struct {
+	service DynamoDB
+	thing   dynamo.Thing
+	item    types.TransactGetItem
+	decode  func(map[string]types.AttributeValue) error
+	err     error
+}
+
+// TxGet reads the entity within the transaction, the value is decoded
+// by the storage codec into val.
+func TxGet[T dynamo.Thing](db *Storage[T], key T, val *T) TxRead {
+	gen, err := db.codec.EncodeKey(key)
+	if err != nil {
+		return TxRead{err: errInvalidKey.New(err)}
+	}
+
+	return TxRead{
+		service: db.service,
+		thing:   key,
+		item: types.TransactGetItem{
+			Get: &types.Get{
+				Key:                      gen,
+				TableName:                aws.String(db.table),
+				ProjectionExpression:     db.schema.Projection,
+				ExpressionAttributeNames: db.schema.ExpectedAttributeNames,
+			},
+		},
+		decode: func(gen map[string]types.AttributeValue) error {
+			obj, err := db.codec.Decode(gen)
+			if err != nil {
+				return err
+			}
+			*val = obj
+			return nil
+		},
+	}
+}
+
+// TransactGet reads a consistent snapshot of items atomically, items might
+// belong to multiple storages (tables and types) accessible by same DynamoDB
+// client.
+//
+//	var author Author
+//	var article Article
+//
+//	ddb.TransactGet(ctx,
+//	  ddb.TxGet(authors, Author{ID: "author:neumann"}, &author),
+//	  ddb.TxGet(articles, Article{Author: "author:neumann", ID: "article:theory_of_set"}, &article),
+//	)
+//
+// All found items are decoded, the error acts as NotFound for the first
+// missing item.
+//
+// All storages must share the DynamoDB client, the transaction is limited
+// to 100 operations.
+func TransactGet(ctx context.Context, ops ...TxRead) error {
+	if len(ops) == 0 {
+		return nil
+	}
+
+	if len(ops) > transactSize {
+		return errTxTooLarge.New(nil, len(ops))
+	}
+
+	seq := make([]types.TransactGetItem, len(ops))
+	for i, op := range ops {
+		if op.err != nil {
+			return op.err
+		}
+		if i > 0 && !sameService(op.service, ops[0].service) {
+			return errTxMixedServices.New(nil)
+		}
+		seq[i] = op.item
+	}
+
+	service, ok := ops[0].service.(DynamoDBTransactGet)
+	if !ok {
+		return errUnsupportedAPI.New(nil, "TransactGetItems")
+	}
+
+	req := &dynamodb.TransactGetItemsInput{TransactItems: seq}
+
+	val, err := service.TransactGetItems(ctx, req)
+	if err != nil {
+		return errServiceIO.New(errService(err))
+	}
+
+	var missing error
+	for i, op := range ops {
+		if i >= len(val.Responses) || val.Responses[i].Item == nil {
+			if missing == nil {
+				missing = errNotFound(nil, op.thing)
+			}
+			continue
+		}
+
+		if err := op.decode(val.Responses[i].Item); err != nil {
+			return errInvalidEntity.New(err)
+		}
+	}
+
+	return missing
+}
+
+// clients of transaction operations are the same, values of non-comparable
+// types are never the same client
+func sameService(a, b DynamoDB) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	if reflect.TypeOf(a) != reflect.TypeOf(b) || !reflect.TypeOf(a).Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+// Unfortunately empty maps are not accepted by DynamoDB
+func nilIfEmpty[K comparable, V any](m map[K]V) map[K]V {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// errTransactionCanceled maps cancellation reasons back to operations
+func errTransactionCanceled(err error, ops []TxWrite, reasons []types.CancellationReason) error {
+	seq := make([]error, len(ops))
+	for i, reason := range reasons {
+		if i >= len(ops) || reason.Code == nil {
+			continue
+		}
+
+		switch *reason.Code {
+		case "None":
+			continue
+		case "ConditionalCheckFailed":
+			seq[i] = errConditionFailed(err, ops[i].thing, ops[i].cond, reason.Item)
+		default:
+			seq[i] = errServiceIO.New(errCancellationReason(reason))
+		}
+	}
+
+	return &transactionCanceled{err: err, reasons: seq}
+}
+
+// errCancellationReason classifies the reason of cancellation
+func errCancellationReason(reason types.CancellationReason) error {
+	err := fmt.Errorf("%s: %s", *reason.Code, aws.ToString(reason.Message))
+
+	switch *reason.Code {
+	case "ThrottlingError", "ProvisionedThroughputExceeded":
+		return &dynamo.ServiceError{Kind: dynamo.ErrThrottled, Err: err}
+	case "ItemCollectionSizeLimitExceeded":
+		return &dynamo.ServiceError{Kind: dynamo.ErrItemTooLarge, Err: err}
+	case "ValidationError":
+		return &dynamo.ServiceError{Kind: dynamo.ErrValidation, Err: err}
+	}
+
+	return err
+}
+
+type transactionCanceled struct {
+	err     error
+	reasons []error
+}
+
+func (e *transactionCanceled) Error() string {
+	return fmt.Sprintf("Transaction Canceled: %v", e.err)
+}
+
+// Reasons returns per-operation errors, nil if operation is not failed
+func (e *transactionCanceled) Reasons() []error { return e.reasons }
+
+func (e *transactionCanceled) Unwrap() []error {
+	seq := []error{e.err}
+	for _, x := range e.reasons {
+		if x != nil {
+			seq = append(seq, x)
+		}
+	}
+	return seq
+}
+
+func (e *transactionCanceled) PreConditionFailed() bool {
+	for _, x := range e.reasons {
+		var pcf *dynamo.PreConditionFailedError
+		if errors.As(x, &pcf) {
+			return true
+		}
+	}
+	return false
+}
+
+// recover AWS TransactionCanceledException
+func recoverTransactionCanceledException(err error) ([]types.CancellationReason, bool) {
+	var e *types.TransactionCanceledException
+
+	if !errors.As(err, &e) {
+		return nil, false
+	}
+
+	return e.CancellationReasons, true
+}
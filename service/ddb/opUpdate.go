@@ -20,14 +20,29 @@ import (
 
 // Update applies a partial patch to entity using update expression abstraction
 func (db *Storage[T]) UpdateWith(ctx context.Context, expression UpdateItemExpression[T], opts ...interface{ WriterOpt(T) }) (T, error) {
+	req, err := db.reqUpdateWith(expression, opts)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	return db.update(ctx, expression.entity, req, opts)
+}
+
+func (db *Storage[T]) reqUpdateWith(expression UpdateItemExpression[T], opts []interface{ WriterOpt(T) }) (*dynamodb.UpdateItemInput, error) {
 	gen, err := db.codec.Encode(expression.entity)
 	if err != nil {
-		return db.undefined, errInvalidEntity.New(err)
+		return nil, errInvalidEntity.New(err)
+	}
+	// Update expression is not extendable, TTL attribute has to be updated explicitly
+	if _, ok := expiresAtOf(opts); ok {
+		return nil, errUnsupportedOpt.New(nil, "TTL with UpdateWith, use UpdateFor on TTL attribute")
 	}
+
 	req := expression.request
 	req.Key = db.codec.KeyOnly(gen)
 	req.TableName = aws.String(db.table)
-	req.ReturnValues = "ALL_NEW"
+	req.ReturnValues = updateReturnValuesOf(opts)
+	req.ReturnValuesOnConditionCheckFailure = returnOnConditionCheckFailureOf(opts)
 
 	maybeUpdateConditionExpression(
 		&req.ConditionExpression,
@@ -36,14 +51,28 @@ func (db *Storage[T]) UpdateWith(ctx context.Context, expression UpdateItemExpre
 		opts,
 	)
 
-	return db.update(ctx, expression.entity, req)
+	return req, nil
 }
 
-// Update applies a partial patch to entity and returns new values
+// Update applies a partial patch to entity and returns new values. The
+// ReturnOld option writes the old item instead, Update returns zero value then.
 func (db *Storage[T]) Update(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) (T, error) {
+	req, err := db.reqUpdate(entity, opts)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	return db.update(ctx, entity, req, opts)
+}
+
+func (db *Storage[T]) reqUpdate(entity T, opts []interface{ WriterOpt(T) }) (*dynamodb.UpdateItemInput, error) {
 	gen, err := db.codec.Encode(entity)
 	if err != nil {
-		return db.undefined, errInvalidEntity.New(err)
+		return nil, errInvalidEntity.New(err)
+	}
+
+	if err := db.maybeTTL(gen, opts); err != nil {
+		return nil, err
 	}
 
 	names := map[string]string{}
@@ -64,8 +93,9 @@ func (db *Storage[T]) Update(ctx context.Context, entity T, opts ...interface{ W
 		ExpressionAttributeValues: values,
 		UpdateExpression:          expression,
 		TableName:                 aws.String(db.table),
-		ReturnValues:              "ALL_NEW",
+		ReturnValues:              updateReturnValuesOf(opts),
 	}
+	req.ReturnValuesOnConditionCheckFailure = returnOnConditionCheckFailureOf(opts)
 
 	maybeUpdateConditionExpression(
 		&req.ConditionExpression,
@@ -74,24 +104,40 @@ func (db *Storage[T]) Update(ctx context.Context, entity T, opts ...interface{ W
 		opts,
 	)
 
-	return db.update(ctx, entity, req)
+	return req, nil
+}
+
+// ReturnOld option requires old item, UpdateItem returns either old or new one
+func updateReturnValuesOf[T dynamo.Thing](opts []interface{ WriterOpt(T) }) types.ReturnValue {
+	if hasReturnOld(opts) {
+		return types.ReturnValueAllOld
+	}
+	return returnValuesOf(opts, types.ReturnValueAllNew)
 }
 
-func (db *Storage[T]) update(ctx context.Context, key dynamo.Thing, req *dynamodb.UpdateItemInput) (T, error) {
+func (db *Storage[T]) update(ctx context.Context, key dynamo.Thing, req *dynamodb.UpdateItemInput, opts []interface{ WriterOpt(T) }) (T, error) {
 	val, err := db.service.UpdateItem(ctx, req)
 	if err != nil {
 		if recoverConditionalCheckFailedException(err) {
-			return db.undefined, errPreConditionFailed(err, key,
-				strings.Contains(*req.ConditionExpression, "attribute_not_exists") || strings.Contains(*req.ConditionExpression, "="),
-				strings.Contains(*req.ConditionExpression, "attribute_exists") || strings.Contains(*req.ConditionExpression, "<>"),
-			)
+			return db.undefined, db.errConditionCheckFailed(err, key, opts)
 		}
-		return db.undefined, errServiceIO.New(err)
+		return db.undefined, errServiceIO.New(errService(err))
+	}
+
+	if req.ReturnValues == types.ReturnValueAllOld && len(val.Attributes) == 0 {
+		returnOldTo(opts, db.undefined)
+		return db.undefined, nil
 	}
 
-	obj, err := db.codec.Decode(val.Attributes)
+	obj, err := db.decodeReturnValues(req.ReturnValues, req.Key, val.Attributes)
 	if err != nil {
-		return db.undefined, errInvalidEntity.New(err)
+		return db.undefined, err
+	}
+
+	// the new item is not known, only old one is returned by the service
+	if hasReturnOld(opts) {
+		returnOldTo(opts, obj)
+		return db.undefined, nil
 	}
 
 	return obj, nil
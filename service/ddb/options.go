@@ -10,6 +10,7 @@ package ddb
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -29,16 +30,36 @@ type DynamoDB interface {
 	BatchWriteItem(ctx context.Context, params *dynamodb.BatchWriteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.BatchWriteItemOutput, error)
 }
 
+// DynamoDBScan declares AWS API used by Scan, it is optional
+type DynamoDBScan interface {
+	Scan(context.Context, *dynamodb.ScanInput, ...func(*dynamodb.Options)) (*dynamodb.ScanOutput, error)
+}
+
+// DynamoDBTransactWrite declares AWS API used by TransactWrite, it is optional
+type DynamoDBTransactWrite interface {
+	TransactWriteItems(context.Context, *dynamodb.TransactWriteItemsInput, ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+// DynamoDBTransactGet declares AWS API used by TransactGet, it is optional
+type DynamoDBTransactGet interface {
+	TransactGetItems(context.Context, *dynamodb.TransactGetItemsInput, ...func(*dynamodb.Options)) (*dynamodb.TransactGetItemsOutput, error)
+}
+
 // Option type to configure the S3
 type Option = opts.Option[Options]
 
 // Config Options
 type Options struct {
-	index         string
-	hashKey       string
-	sortKey       string
-	useStrictType bool
-	service       DynamoDB
+	index            string
+	localIndex       bool
+	hashKey          string
+	sortKey          string
+	useStrictType    bool
+	batchConcurrency int
+	batchRetry       int
+	batchBackoff     time.Duration
+	ttlAttribute     string
+	service          DynamoDB
 }
 
 func (c *Options) checkRequired() error {
@@ -51,6 +72,10 @@ var (
 	// Set Global Secondary Index for the session
 	WithGlobalSecondaryIndex = opts.ForName[Options, string]("index")
 
+	// Set Local Secondary Index for the session, unlike global one it
+	// supports strongly consistent reads
+	WithLocalSecondaryIndex = opts.FMap(optsLocalIndex)
+
 	// Configure the custom name for HashKey, default one is "prefix"
 	WithHashKey = opts.ForName[Options, string]("hashKey")
 
@@ -65,6 +90,22 @@ var (
 	// It demand that storage schema "knows" all type attributes.
 	WithStrictType = opts.ForName[Options, bool]("useStrictType")
 
+	// Number of concurrent requests used by batch I/O, default one is 4
+	WithBatchConcurrency = opts.ForName[Options, int]("batchConcurrency")
+
+	// Number of retries of unprocessed items used by batch I/O, default one is 8.
+	// Retries are also bounded by the context deadline.
+	WithBatchRetry = opts.ForName[Options, int]("batchRetry")
+
+	// Base delay of exponential backoff between retries of unprocessed items,
+	// default one is 50ms.
+	WithBatchBackoff = opts.ForName[Options, time.Duration]("batchBackoff")
+
+	// Configure the name of time-to-live attribute, it is required by
+	// dynamo.TTL and dynamo.ExpiresAt options. The attribute has to be
+	// enabled as TTL attribute of the table.
+	WithTTLAttribute = opts.ForName[Options, string]("ttlAttribute")
+
 	// Set DynamoDB client for the client
 	WithService = opts.ForType[Options, DynamoDB]()
 
@@ -81,11 +122,20 @@ var (
 // creates default config options
 func optsDefault() Options {
 	return Options{
-		hashKey: "prefix",
-		sortKey: "suffix",
+		hashKey:          "prefix",
+		sortKey:          "suffix",
+		batchConcurrency: 4,
+		batchRetry:       8,
+		batchBackoff:     50 * time.Millisecond,
 	}
 }
 
+func optsLocalIndex(c *Options, index string) error {
+	c.index = index
+	c.localIndex = true
+	return nil
+}
+
 func optsDefaultDDB(c *Options) error {
 	cfg, err := config.LoadDefaultConfig(context.Background())
 	if err != nil {
@@ -0,0 +1,162 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb
+
+import (
+	"errors"
+	"maps"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3"
+)
+
+// ReturnValues option for Remove and Update, it defines attributes of
+// the item returned by the operation:
+//
+//	types.ReturnValueNone returns empty item, it saves read capacity
+//	types.ReturnValueAllOld returns item before the operation (default for Remove)
+//	types.ReturnValueAllNew returns item after the operation (default for Update)
+//	types.ReturnValueUpdatedOld returns keys and updated attributes before update
+//	types.ReturnValueUpdatedNew returns keys and updated attributes after update
+//
+// Put supports only types.ReturnValueNone, use ReturnOld to learn what was
+// overwritten. Remove supports only types.ReturnValueNone and
+// types.ReturnValueAllOld.
+func ReturnValues[T dynamo.Thing](rv types.ReturnValue) interface{ WriterOpt(T) } {
+	return returnValues[T](rv)
+}
+
+type returnValues[T dynamo.Thing] types.ReturnValue
+
+func (returnValues[T]) WriterOpt(T) {}
+
+func (rv returnValues[T]) ReturnValues() types.ReturnValue { return types.ReturnValue(rv) }
+
+// ReturnOld option for Put, Remove and Update, it writes the item as it
+// was before the operation to val. The val is set to zero value if the
+// item did not exist. DynamoDB returns either old or new item, Update with
+// the option returns zero value instead of the new item.
+func ReturnOld[T dynamo.Thing](val *T) interface{ WriterOpt(T) } {
+	return returnOld[T]{val: val}
+}
+
+type returnOld[T dynamo.Thing] struct{ val *T }
+
+func (returnOld[T]) WriterOpt(T) {}
+
+func (returnOld[T]) ReturnValues() types.ReturnValue { return types.ReturnValueAllOld }
+
+// ReturnValuesOnConditionCheckFailure option for Put, Remove and Update,
+// the failed condition hands back the current item inside the error.
+// Use CurrentValue to recover it.
+func ReturnValuesOnConditionCheckFailure[T dynamo.Thing]() interface{ WriterOpt(T) } {
+	return returnOnConditionCheckFailure[T]{}
+}
+
+type returnOnConditionCheckFailure[T dynamo.Thing] struct{}
+
+func (returnOnConditionCheckFailure[T]) WriterOpt(T) {}
+
+func (returnOnConditionCheckFailure[T]) ReturnValuesOnConditionCheckFailure() types.ReturnValuesOnConditionCheckFailure {
+	return types.ReturnValuesOnConditionCheckFailureAllOld
+}
+
+// CurrentValue returns the item that has failed the condition of write
+// operation, it requires ReturnValuesOnConditionCheckFailure option.
+//
+//	_, err := db.Remove(ctx, key, ReturnValuesOnConditionCheckFailure[Person](), ...)
+//	if cur, ok := ddb.CurrentValue[Person](err); ok {
+//		...
+//	}
+func CurrentValue[T dynamo.Thing](err error) (T, bool) {
+	var e *dynamo.PreConditionFailedError
+	if !errors.As(err, &e) || e.Current == nil {
+		return *new(T), false
+	}
+
+	val, ok := e.Current.(T)
+	return val, ok
+}
+
+func returnValuesOf[T any](opts []interface{ WriterOpt(T) }, def types.ReturnValue) types.ReturnValue {
+	for _, opt := range opts {
+		if v, ok := opt.(interface{ ReturnValues() types.ReturnValue }); ok {
+			return v.ReturnValues()
+		}
+	}
+	return def
+}
+
+func returnOnConditionCheckFailureOf[T any](opts []interface{ WriterOpt(T) }) types.ReturnValuesOnConditionCheckFailure {
+	for _, opt := range opts {
+		if v, ok := opt.(interface {
+			ReturnValuesOnConditionCheckFailure() types.ReturnValuesOnConditionCheckFailure
+		}); ok {
+			return v.ReturnValuesOnConditionCheckFailure()
+		}
+	}
+	return ""
+}
+
+func hasReturnOld[T dynamo.Thing](opts []interface{ WriterOpt(T) }) bool {
+	for _, opt := range opts {
+		if _, ok := opt.(returnOld[T]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// writes old value of the item to ReturnOld option
+func returnOldTo[T dynamo.Thing](opts []interface{ WriterOpt(T) }, val T) {
+	for _, opt := range opts {
+		if v, ok := opt.(returnOld[T]); ok && v.val != nil {
+			*v.val = val
+		}
+	}
+}
+
+// decodes attributes returned by the write operation, keys are injected
+// to partial (UPDATED_OLD, UPDATED_NEW) attributes.
+func (db *Storage[T]) decodeReturnValues(rv types.ReturnValue, key, attrs map[string]types.AttributeValue) (T, error) {
+	switch rv {
+	case types.ReturnValueNone:
+		return db.undefined, nil
+	case types.ReturnValueUpdatedOld, types.ReturnValueUpdatedNew:
+		gen := make(map[string]types.AttributeValue, len(attrs)+len(key))
+		maps.Copy(gen, attrs)
+		maps.Copy(gen, key)
+		attrs = gen
+	}
+
+	obj, err := db.codec.Decode(attrs)
+	if err != nil {
+		return db.undefined, errInvalidEntity.New(err)
+	}
+
+	return obj, nil
+}
+
+// errConditionFailed with the current item, if it is returned by service
+func (db *Storage[T]) errConditionCheckFailed(err error, key dynamo.Thing, opts []interface{ WriterOpt(T) }) error {
+	var item map[string]types.AttributeValue
+	var e *types.ConditionalCheckFailedException
+	if errors.As(err, &e) {
+		item = e.Item
+	}
+
+	failure := errConditionFailed(err, key, conditionsOf(opts), item)
+	if len(item) != 0 {
+		if obj, derr := db.codec.Decode(item); derr == nil {
+			failure.Current = obj
+		}
+	}
+
+	return failure
+}
@@ -0,0 +1,162 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3/service/ddb"
+	"github.com/fogfish/dynamo/v3/service/ddb/ddbfake"
+	"github.com/fogfish/faults"
+	"github.com/fogfish/it/v2"
+)
+
+func TestReturnValues(t *testing.T) {
+	name := ddb.ClauseFor[person, string]("Name")
+	key := person{Prefix: "dead:beef", Suffix: "1"}
+	val := person{Prefix: "dead:beef", Suffix: "1", Name: "Verner", Age: 64}
+
+	setup := func() *ddb.Storage[person] {
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(ddbfake.New())))
+		if err := db.Put(context.Background(), val); err != nil {
+			t.Fatal(err)
+		}
+		return db
+	}
+
+	t.Run("PutReturnOld", func(t *testing.T) {
+		db := setup()
+
+		var old person
+		err := db.Put(context.Background(), person{Prefix: "dead:beef", Suffix: "1", Name: "Jim"}, ddb.ReturnOld(&old))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(old, val),
+		)
+
+		old = val
+		err = db.Put(context.Background(), person{Prefix: "dead:beef", Suffix: "2"}, ddb.ReturnOld(&old))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(old, person{}),
+		)
+	})
+
+	t.Run("PutUnsupported", func(t *testing.T) {
+		db := setup()
+
+		err := db.Put(context.Background(), val, ddb.ReturnValues[person](types.ReturnValueAllNew))
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+
+	t.Run("RemoveNone", func(t *testing.T) {
+		db := setup()
+
+		old, err := db.Remove(context.Background(), key, ddb.ReturnValues[person](types.ReturnValueNone))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(old, person{}),
+		)
+
+		_, err = db.Get(context.Background(), key)
+		it.Then(t).Should(it.True(faults.IsNotFound(err)))
+	})
+
+	t.Run("UpdateUpdatedOld", func(t *testing.T) {
+		db := setup()
+
+		old, err := db.Update(context.Background(),
+			person{Prefix: "dead:beef", Suffix: "1", Age: 65},
+			ddb.ReturnValues[person](types.ReturnValueUpdatedOld),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(old, person{Prefix: "dead:beef", Suffix: "1", Age: 64}),
+		)
+	})
+
+	t.Run("UpdateUpdatedNew", func(t *testing.T) {
+		db := setup()
+
+		new, err := db.UpdateWith(context.Background(),
+			ddb.Updater(key, ddb.UpdateFor[person, string]("Name").Set("Jim")),
+			ddb.ReturnValues[person](types.ReturnValueUpdatedNew),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(new, person{Prefix: "dead:beef", Suffix: "1", Name: "Jim"}),
+		)
+	})
+
+	t.Run("UpdateReturnOld", func(t *testing.T) {
+		db := setup()
+
+		var old person
+		new, err := db.Update(context.Background(),
+			person{Prefix: "dead:beef", Suffix: "1", Age: 65},
+			ddb.ReturnOld(&old),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(new, person{}),
+			it.Equiv(old, val),
+		)
+
+		new, err = db.Get(context.Background(), key)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(new, person{Prefix: "dead:beef", Suffix: "1", Name: "Verner", Age: 65}),
+		)
+
+		old = val
+		new, err = db.UpdateWith(context.Background(),
+			ddb.Updater(person{Prefix: "dead:beef", Suffix: "2"}, ddb.UpdateFor[person, string]("Name").Set("Jim")),
+			ddb.ReturnOld(&old),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(new, person{}),
+			it.Equiv(old, person{}),
+		)
+	})
+
+	t.Run("RemoveUnsupported", func(t *testing.T) {
+		db := setup()
+
+		_, err := db.Remove(context.Background(), key, ddb.ReturnValues[person](types.ReturnValueUpdatedOld))
+		it.Then(t).ShouldNot(it.Nil(err))
+
+		_, err = db.Get(context.Background(), key)
+		it.Then(t).Should(it.Nil(err))
+	})
+
+	t.Run("OnConditionCheckFailure", func(t *testing.T) {
+		db := setup()
+
+		_, err := db.Remove(context.Background(), key,
+			name.Eq("Jim"),
+			ddb.ReturnValuesOnConditionCheckFailure[person](),
+		)
+		cur, ok := ddb.CurrentValue[person](err)
+		it.Then(t).Should(
+			it.True(faults.IsPreConditionFailed(err)),
+			it.True(ok),
+			it.Equiv(cur, val),
+		)
+
+		_, err = db.Update(context.Background(), val, name.Eq("Jim"))
+		_, ok = ddb.CurrentValue[person](err)
+		it.Then(t).Should(
+			it.True(faults.IsPreConditionFailed(err)),
+			it.True(!ok),
+		)
+	})
+}
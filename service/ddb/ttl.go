@@ -0,0 +1,95 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file implements time-to-live of items
+//
+
+package ddb
+
+import (
+	"maps"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// expiry of the item defined by options (e.g. dynamo.TTL)
+func expiresAtOf[T any](opts []interface{ WriterOpt(T) }) (time.Time, bool) {
+	for _, opt := range opts {
+		if v, ok := opt.(interface{ ExpiresAt() time.Time }); ok {
+			return v.ExpiresAt(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// checks if options requests to hide expired items (dynamo.SkipExpired)
+func skipExpiredOf[O any](opts []O) bool {
+	for _, opt := range opts {
+		if v, ok := any(opt).(interface{ SkipExpired() bool }); ok && v.SkipExpired() {
+			return true
+		}
+	}
+	return false
+}
+
+// sets TTL attribute of the item if the expiry is defined by options
+func (db *Storage[T]) maybeTTL(gen map[string]types.AttributeValue, opts []interface{ WriterOpt(T) }) error {
+	t, ok := expiresAtOf(opts)
+	if !ok {
+		return nil
+	}
+
+	if db.ttlAttribute == "" {
+		return errUnsupportedOpt.New(nil, "TTL without TTL attribute, see ddb.WithTTLAttribute")
+	}
+
+	gen[db.ttlAttribute] = &types.AttributeValueMemberN{Value: strconv.FormatInt(t.Unix(), 10)}
+	return nil
+}
+
+// checks if the item is logically expired
+func (db *Storage[T]) isExpired(item map[string]types.AttributeValue) bool {
+	v, ok := item[db.ttlAttribute].(*types.AttributeValueMemberN)
+	if !ok {
+		return false
+	}
+
+	ts, err := strconv.ParseInt(v.Value, 10, 64)
+	return err == nil && ts <= time.Now().Unix()
+}
+
+// filter expression that hides expired items
+func (db *Storage[T]) ttlFilterExpression(names map[string]string, values map[string]types.AttributeValue) string {
+	names["#__ttl_attr__"] = db.ttlAttribute
+	values[":__ttl_now__"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)}
+
+	return "(attribute_not_exists(#__ttl_attr__) or #__ttl_attr__ > :__ttl_now__)"
+}
+
+// projection of the type extended with TTL attribute, if it is not
+// the part of the type.
+func (db *Storage[T]) projectionWithTTL() (*string, map[string]string) {
+	if db.schema.Projection == nil {
+		return nil, db.schema.ExpectedAttributeNames
+	}
+
+	for _, attr := range db.schema.ExpectedAttributeNames {
+		if attr == db.ttlAttribute {
+			return db.schema.Projection, db.schema.ExpectedAttributeNames
+		}
+	}
+
+	names := maps.Clone(db.schema.ExpectedAttributeNames)
+	names["#__ttl_attr__"] = db.ttlAttribute
+
+	return aws.String(*db.schema.Projection + ", #__ttl_attr__"), names
+}
@@ -0,0 +1,114 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package ddb_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/service/ddb"
+	"github.com/fogfish/dynamo/v3/service/ddb/ddbfake"
+	"github.com/fogfish/faults"
+	"github.com/fogfish/it/v2"
+)
+
+func TestTTL(t *testing.T) {
+	fake := ddbfake.New()
+	db := ddb.Must(ddb.New[person]("test",
+		ddb.WithDynamoDB(fake),
+		ddb.WithTTLAttribute("ttl"),
+		ddb.WithStrictType(true),
+	))
+
+	expiresAt := time.Now().Add(-time.Minute)
+	fresh := person{Prefix: "dead:beef", Suffix: "1", Name: "fresh"}
+	stale := person{Prefix: "dead:beef", Suffix: "2", Name: "stale"}
+
+	t.Run("Put", func(t *testing.T) {
+		err := db.Put(context.Background(), fresh, dynamo.TTL[person](time.Hour))
+		it.Then(t).Should(it.Nil(err))
+
+		err = db.Put(context.Background(), stale, dynamo.ExpiresAt[person](expiresAt))
+		it.Then(t).Should(it.Nil(err))
+
+		val, err := fake.GetItem(context.Background(), &dynamodb.GetItemInput{
+			TableName: aws.String("test"),
+			Key: map[string]types.AttributeValue{
+				"prefix": &types.AttributeValueMemberS{Value: "dead:beef"},
+				"suffix": &types.AttributeValueMemberS{Value: "2"},
+			},
+		})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equiv(val.Item["ttl"], types.AttributeValue(&types.AttributeValueMemberN{Value: strconv.FormatInt(expiresAt.Unix(), 10)})),
+		)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		_, err := db.Update(context.Background(), stale, dynamo.ExpiresAt[person](expiresAt))
+		it.Then(t).Should(it.Nil(err))
+
+		_, err = db.UpdateWith(context.Background(),
+			ddb.Updater(stale, ddb.UpdateFor[person, string]("Name").Set("x")),
+			dynamo.TTL[person](time.Hour),
+		)
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		_, err := db.Get(context.Background(), stale)
+		it.Then(t).Should(it.Nil(err))
+
+		_, err = db.Get(context.Background(), stale, dynamo.SkipExpired[person]())
+		it.Then(t).Should(it.True(faults.IsNotFound(err)))
+
+		val, err := db.Get(context.Background(), fresh, dynamo.SkipExpired[person]())
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(val.Name, "fresh"),
+		)
+	})
+
+	t.Run("Match", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), person{Prefix: "dead:beef"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 2),
+		)
+
+		seq, _, err = db.Match(context.Background(), person{Prefix: "dead:beef"},
+			dynamo.SkipExpired[person](),
+			ddb.Filter(ddb.ClauseFor[person, string]("Name").HasPrefix("f")),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 1),
+			it.Equal(seq[0].Name, "fresh"),
+		)
+	})
+
+	t.Run("Undefined", func(t *testing.T) {
+		db := ddb.Must(ddb.New[person]("test", ddb.WithDynamoDB(fake)))
+
+		err := db.Put(context.Background(), fresh, dynamo.TTL[person](time.Hour))
+		it.Then(t).ShouldNot(it.Nil(err))
+
+		_, err = db.Get(context.Background(), fresh, dynamo.SkipExpired[person]())
+		it.Then(t).ShouldNot(it.Nil(err))
+
+		_, _, err = db.Match(context.Background(), fresh, dynamo.SkipExpired[person]())
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+}
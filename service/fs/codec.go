@@ -0,0 +1,73 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package fs
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+)
+
+// file extension of persisted entities, it also prevents conflicts
+// between files and directories (e.g. keys `a/b` and `a/b/c`).
+const ext = ".json"
+
+/*
+Codec is utility to encode/decode objects to file system representation
+*/
+type codec[T dynamo.Thing] struct {
+	root     string
+	prefixes curie.Prefixes
+}
+
+func newCodec[T dynamo.Thing](root string, prefixes curie.Prefixes) *codec[T] {
+	if prefixes == nil {
+		return &codec[T]{root: root, prefixes: curie.Namespaces{}}
+	}
+
+	return &codec[T]{root: root, prefixes: prefixes}
+}
+
+// EncodeKey encodes key to slash-separated path, same as s3 service does.
+func (codec codec[T]) EncodeKey(key dynamo.Thing) string {
+	hkey := curie.URI(codec.prefixes, key.HashKey())
+	skey := curie.URI(codec.prefixes, key.SortKey())
+
+	if skey == "" {
+		return hkey
+	}
+
+	return hkey + "/" + skey
+}
+
+// EncodePath encodes key to file path, the path is guaranteed to be
+// within the root directory.
+func (codec codec[T]) EncodePath(key dynamo.Thing) (string, error) {
+	if key.HashKey() == "" {
+		return "", errInvalidKey.New(nil)
+	}
+
+	file := filepath.FromSlash(codec.EncodeKey(key) + ext)
+	if !filepath.IsLocal(file) {
+		return "", errInvalidKey.New(nil)
+	}
+
+	return filepath.Join(codec.root, file), nil
+}
+
+// DecodePath decodes file path (relative to the root) back to key
+func (codec codec[T]) DecodePath(path string) (string, bool) {
+	if !strings.HasSuffix(path, ext) {
+		return "", false
+	}
+
+	return filepath.ToSlash(strings.TrimSuffix(path, ext)), true
+}
@@ -0,0 +1,28 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package fs
+
+import (
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/faults"
+)
+
+const (
+	errUndefinedRoot  = faults.Type("undefined root directory")
+	errInvalidKey     = faults.Type("invalid key")
+	errServiceIO      = faults.Type("service i/o failed")
+	errInvalidEntity  = faults.Type("invalid entity")
+	errUnsupportedOpt = faults.Safe1[string]("unsupported option %s")
+	errInvalidLimit   = faults.Safe1[int32]("invalid limit %d, it must be positive")
+)
+
+// NotFound is an error to handle unknown elements
+func errNotFound(err error, thing dynamo.Thing) error {
+	return &dynamo.NotFoundError{Thing: thing, Err: err}
+}
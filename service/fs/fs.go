@@ -0,0 +1,55 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+// Package fs implements dynamo.KeyVal on the local file system. The storage
+// follows the model of s3 service: the key is mapped to the file path and
+// the entity is persisted as JSON file under the root directory. It is
+// intended for command line utilities and offline development.
+package fs
+
+import (
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/schema"
+	"github.com/fogfish/opts"
+)
+
+type Storage[T dynamo.Thing] struct {
+	Options
+	root      string
+	codec     *codec[T]
+	schema    *schema.Schema[T]
+	undefined T
+}
+
+// Must constraint for api factory
+func Must[T dynamo.Thing](keyval *Storage[T], err error) *Storage[T] {
+	if err != nil {
+		panic(err)
+	}
+
+	return keyval
+}
+
+// New creates instance of file system storage at the root directory
+func New[T dynamo.Thing](root string, opt ...Option) (*Storage[T], error) {
+	conf := optsDefault()
+	if err := opts.Apply(&conf, opt); err != nil {
+		return nil, err
+	}
+
+	if root == "" {
+		return nil, errUndefinedRoot.New(nil)
+	}
+
+	return &Storage[T]{
+		Options: conf,
+		root:    root,
+		codec:   newCodec[T](root, conf.prefixes),
+		schema:  schema.New[T](),
+	}, nil
+}
@@ -0,0 +1,165 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package fs_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/kvtest"
+	"github.com/fogfish/dynamo/v3/service/fs"
+	"github.com/fogfish/it/v2"
+)
+
+type Person struct {
+	Prefix curie.IRI `json:"prefix,omitempty"`
+	Suffix curie.IRI `json:"suffix,omitempty"`
+	Name   string    `json:"name,omitempty"`
+}
+
+func (p Person) HashKey() curie.IRI { return p.Prefix }
+func (p Person) SortKey() curie.IRI { return p.Suffix }
+
+func TestNew(t *testing.T) {
+	_, err := fs.New[Person]("")
+	it.Then(t).ShouldNot(it.Nil(err))
+
+	db, err := fs.New[Person](t.TempDir())
+	it.Then(t).Should(it.Nil(err)).ShouldNot(it.Nil(db))
+}
+
+func TestConformance(t *testing.T) {
+	root := t.TempDir()
+
+	kvtest.TestKeyVal(t,
+		func() dynamo.KeyVal[kvtest.Person] {
+			dir, err := os.MkdirTemp(root, "kv")
+			if err != nil {
+				panic(err)
+			}
+			return fs.Must(fs.New[kvtest.Person](dir))
+		},
+		kvtest.WithConditions(false),
+	)
+}
+
+func TestPut(t *testing.T) {
+	root := t.TempDir()
+	db := fs.Must(fs.New[Person](root))
+
+	t.Run("Layout", func(t *testing.T) {
+		err := db.Put(context.Background(), Person{Prefix: "person:a", Suffix: "b/c", Name: "x"})
+		it.Then(t).Should(it.Nil(err))
+
+		_, err = os.Stat(filepath.Join(root, "person:a", "b", "c.json"))
+		it.Then(t).Should(it.Nil(err))
+
+		// temporary files are not left behind
+		seq, err := os.ReadDir(filepath.Join(root, "person:a", "b"))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 1),
+		)
+	})
+
+	t.Run("InvalidKey", func(t *testing.T) {
+		err := db.Put(context.Background(), Person{Prefix: "..", Suffix: "../x"})
+		it.Then(t).ShouldNot(it.Nil(err))
+
+		err = db.Put(context.Background(), Person{Suffix: "x"})
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+}
+
+func TestMatch(t *testing.T) {
+	db := fs.Must(fs.New[Person](t.TempDir()))
+	for i := 0; i < 5; i++ {
+		db.Put(context.Background(), Person{Prefix: "person:a", Suffix: curie.IRI("s/" + strconv.Itoa(i))})
+	}
+	db.Put(context.Background(), Person{Prefix: "person:a", Suffix: "s"})
+	db.Put(context.Background(), Person{Prefix: "person:ab", Suffix: "s/0"})
+
+	t.Run("Nested", func(t *testing.T) {
+		seq, cur, err := db.Match(context.Background(), Person{Prefix: "person:a", Suffix: "s/"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 5),
+			it.True(cur == nil),
+		)
+	})
+
+	t.Run("Prefix", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "person:a"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 7),
+			it.Equal(seq[0].Suffix, "s"),
+		)
+	})
+
+	t.Run("Cursor", func(t *testing.T) {
+		seq, cur, err := db.Match(context.Background(), Person{Prefix: "person:a", Suffix: "s/"},
+			dynamo.Limit[Person](3),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 3),
+		).ShouldNot(
+			it.Nil(cur),
+		)
+
+		seq, cur, err = db.Match(context.Background(), Person{Prefix: "person:a", Suffix: "s/"},
+			dynamo.Limit[Person](3), cur,
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 2),
+			it.Equal(seq[0].Suffix, "s/3"),
+			it.True(cur == nil),
+		)
+	})
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+		for _, limit := range []int32{0, -1} {
+			_, _, err := db.Match(context.Background(), Person{Prefix: "person:a", Suffix: "s/"},
+				dynamo.Limit[Person](limit),
+			)
+			it.Then(t).ShouldNot(it.Nil(err))
+		}
+	})
+
+	t.Run("Seq", func(t *testing.T) {
+		n := 0
+		for _, err := range db.MatchSeq(context.Background(), Person{Prefix: "person:a", Suffix: "s/"},
+			dynamo.Limit[Person](2),
+		) {
+			it.Then(t).Should(it.Nil(err))
+			n++
+		}
+		it.Then(t).Should(it.Equal(n, 5))
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "person:x", Suffix: "s/"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 0),
+		)
+	})
+
+	t.Run("Reverse", func(t *testing.T) {
+		_, _, err := db.Match(context.Background(), Person{Prefix: "person:a"}, dynamo.Reverse[Person]())
+		it.Then(t).ShouldNot(it.Nil(err))
+	})
+}
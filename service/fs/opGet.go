@@ -0,0 +1,50 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+)
+
+// Get item from storage
+func (db *Storage[T]) Get(ctx context.Context, key T, opts ...interface{ GetterOpt(T) }) (T, error) {
+	if err := checkOpts(opts); err != nil {
+		return db.undefined, err
+	}
+
+	file, err := db.codec.EncodePath(key)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	return db.read(file, key)
+}
+
+func (db *Storage[T]) read(file string, key T) (T, error) {
+	val, err := os.ReadFile(file)
+	if err != nil {
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return db.undefined, errNotFound(err, key)
+		default:
+			return db.undefined, errServiceIO.New(err)
+		}
+	}
+
+	var entity T
+	if err := json.Unmarshal(val, &entity); err != nil {
+		return db.undefined, errInvalidEntity.New(err)
+	}
+
+	return entity, nil
+}
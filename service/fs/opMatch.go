@@ -0,0 +1,156 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package fs
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"iter"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/iterator"
+)
+
+func (db *Storage[T]) MatchKey(ctx context.Context, key dynamo.Thing, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	return db.match(ctx, key, opts)
+}
+
+// MatchSeq lazily iterates over all entities matching the pattern, it
+// follows cursor until the prefix or MaxItems is exhausted.
+func (db *Storage[T]) MatchSeq(ctx context.Context, key T, opts ...interface{ MatcherOpt(T) }) iter.Seq2[T, error] {
+	return iterator.Seq(ctx,
+		func(ctx context.Context, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+			return db.Match(ctx, key, opts...)
+		},
+		opts,
+	)
+}
+
+// Match entities with the key. The key is encoded to path, same as
+// s3 service does, and all entities with the path prefix are matched
+// in the ascending order.
+func (db *Storage[T]) Match(ctx context.Context, key T, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	return db.match(ctx, key, opts)
+}
+
+func (db *Storage[T]) match(ctx context.Context, key dynamo.Thing, opts []interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	if err := checkOpts(opts); err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		limit int32  = 1000
+		after string = ""
+	)
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case interface{ Limit() int32 }:
+			if v.Limit() <= 0 {
+				return nil, nil, errInvalidLimit.New(nil, v.Limit())
+			}
+			limit = v.Limit()
+		case interface{ Reverse() bool }:
+			// entities are listed in ascending order only
+			if v.Reverse() {
+				return nil, nil, errUnsupportedOpt.New(nil, "Reverse")
+			}
+		case dynamo.Thing:
+			after = db.codec.EncodeKey(v)
+		}
+	}
+
+	keys, err := db.list(ctx, db.codec.EncodeKey(key), after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var next interface{ MatcherOpt(T) }
+	if len(keys) > int(limit) {
+		keys = keys[:limit]
+		next = dynamo.Cursor[T](&cursor{hashKey: keys[limit-1]})
+	}
+
+	seq := make([]T, 0, len(keys))
+	for _, k := range keys {
+		file := filepath.Join(db.root, filepath.FromSlash(k+ext))
+		val, err := db.read(file, db.undefined)
+		if err != nil {
+			// the entity is removed after listing
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, nil, err
+		}
+		seq = append(seq, val)
+	}
+
+	return seq, next, nil
+}
+
+// lists keys with the prefix, which follows after the key, in ascending order
+func (db *Storage[T]) list(ctx context.Context, prefix, after string) ([]string, error) {
+	base := path.Dir(prefix)
+	if !strings.Contains(prefix, "/") {
+		base = "."
+	}
+
+	root := filepath.Join(db.root, filepath.FromSlash(base))
+	seq := make([]string, 0)
+	err := filepath.WalkDir(root, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(db.root, file)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			// skips directories that cannot contain matching keys
+			if file != root && !strings.HasPrefix(prefix, rel+"/") && !strings.HasPrefix(rel, prefix) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		key, ok := db.codec.DecodePath(rel)
+		if ok && strings.HasPrefix(key, prefix) && key > after {
+			seq = append(seq, key)
+		}
+
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return seq, nil
+		}
+		return nil, errServiceIO.New(err)
+	}
+
+	slices.Sort(seq)
+
+	return seq, nil
+}
+
+type cursor struct{ hashKey, sortKey string }
+
+func (c cursor) HashKey() curie.IRI { return curie.IRI(c.hashKey) }
+func (c cursor) SortKey() curie.IRI { return curie.IRI(c.sortKey) }
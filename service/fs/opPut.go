@@ -0,0 +1,91 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Put writes entity. The entity is written to temporary file, which is
+// atomically renamed to the target one, readers never observe partial writes.
+func (db *Storage[T]) Put(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) error {
+	if err := checkOpts(opts); err != nil {
+		return err
+	}
+
+	file, err := db.codec.EncodePath(entity)
+	if err != nil {
+		return err
+	}
+
+	gen, err := json.Marshal(entity)
+	if err != nil {
+		return errInvalidEntity.New(err)
+	}
+
+	if err := write(file, gen); err != nil {
+		return errServiceIO.New(err)
+	}
+
+	return nil
+}
+
+// file system does not support conditional expressions (e.g. ddb.ClauseFor)
+// and time-to-live of files (e.g. dynamo.TTL, dynamo.SkipExpired)
+func checkOpts[O any](opts []O) error {
+	for _, opt := range opts {
+		switch any(opt).(type) {
+		case interface {
+			Apply(map[string]string, map[string]types.AttributeValue) string
+		}:
+			return errUnsupportedOpt.New(nil, "conditional expression")
+		case interface{ ExpiresAt() time.Time }:
+			return errUnsupportedOpt.New(nil, "TTL")
+		case interface{ SkipExpired() bool }:
+			return errUnsupportedOpt.New(nil, "SkipExpired")
+		}
+	}
+	return nil
+}
+
+func write(file string, data []byte) error {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// temporary file does not have the extension, it is not visible to Match
+	fd, err := os.CreateTemp(dir, ".put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(fd.Name())
+
+	if _, err := fd.Write(data); err != nil {
+		fd.Close()
+		return err
+	}
+
+	if err := fd.Sync(); err != nil {
+		fd.Close()
+		return err
+	}
+
+	if err := fd.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(fd.Name(), file)
+}
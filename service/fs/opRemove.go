@@ -0,0 +1,37 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package fs
+
+import (
+	"context"
+	"os"
+)
+
+// Remove discards the entity from the storage, it returns removed entity
+func (db *Storage[T]) Remove(ctx context.Context, key T, opts ...interface{ WriterOpt(T) }) (T, error) {
+	if err := checkOpts(opts); err != nil {
+		return db.undefined, err
+	}
+
+	file, err := db.codec.EncodePath(key)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	obj, err := db.read(file, key)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	if err := os.Remove(file); err != nil {
+		return db.undefined, errServiceIO.New(err)
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,42 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package fs
+
+import (
+	"context"
+
+	"github.com/fogfish/faults"
+)
+
+// Update applies a partial patch to entity and returns new values
+func (db *Storage[T]) Update(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) (T, error) {
+	if err := checkOpts(opts); err != nil {
+		return db.undefined, err
+	}
+
+	existing, err := db.Get(ctx, entity)
+	if err != nil {
+		if faults.IsNotFound(err) {
+			if err := db.Put(ctx, entity); err != nil {
+				return db.undefined, err
+			}
+			return entity, nil
+		}
+
+		return db.undefined, err
+	}
+
+	updated := db.schema.Merge(entity, existing)
+
+	if err := db.Put(ctx, updated); err != nil {
+		return db.undefined, err
+	}
+
+	return updated, nil
+}
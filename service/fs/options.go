@@ -0,0 +1,34 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package fs
+
+import (
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/opts"
+)
+
+// Option type to configure the file system storage
+type Option = opts.Option[Options]
+
+// Config Options
+type Options struct {
+	prefixes curie.Prefixes
+}
+
+var (
+	// Configure CURIE prefixes
+	WithPrefixes = opts.ForType[Options, curie.Prefixes]()
+)
+
+// NewConfig creates Config with default options
+func optsDefault() Options {
+	return Options{
+		prefixes: curie.Namespaces{},
+	}
+}
@@ -0,0 +1,23 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package mem
+
+import (
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/faults"
+)
+
+const (
+	errInvalidKey = faults.Type("invalid key")
+)
+
+// NotFound is an error to handle unknown elements
+func errNotFound(err error, key dynamo.Thing) error {
+	return &dynamo.NotFoundError{Thing: key, Err: err}
+}
@@ -0,0 +1,86 @@
+//
+// Copyright (C) 2019 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+// Package mem implements in-memory storage, it is intended for unit
+// testing of applications without AWS. The storage keeps items in
+// partitions sorted by sort key, it follows semantic of DynamoDB: pattern
+// matching with `begins_with`, pagination with Limit and Cursor,
+// evaluation of conditions (ddb.ClauseFor) and update expressions
+// (ddb.UpdateFor).
+package mem
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+)
+
+// Storage type
+type Storage[T dynamo.Thing] struct {
+	mu        sync.RWMutex
+	heap      map[curie.IRI][]entry
+	undefined T
+}
+
+// item of partition
+type entry struct {
+	sortKey curie.IRI
+	item    ddbexpr.Item
+}
+
+// New creates instance of in-memory storage
+func New[T dynamo.Thing]() *Storage[T] {
+	return &Storage[T]{heap: map[curie.IRI][]entry{}}
+}
+
+// lookup item in the partition, it returns position of the item
+func (db *Storage[T]) lookup(key dynamo.Thing) (int, bool) {
+	return slices.BinarySearchFunc(db.heap[key.HashKey()], key.SortKey(),
+		func(e entry, sk curie.IRI) int { return strings.Compare(string(e.sortKey), string(sk)) },
+	)
+}
+
+// get item by key, the nil item is returned if it does not exist
+func (db *Storage[T]) get(key dynamo.Thing) ddbexpr.Item {
+	if at, has := db.lookup(key); has {
+		return db.heap[key.HashKey()][at].item
+	}
+	return nil
+}
+
+// put item to partition
+func (db *Storage[T]) put(key dynamo.Thing, item ddbexpr.Item) {
+	at, has := db.lookup(key)
+	if has {
+		db.heap[key.HashKey()][at].item = item
+		return
+	}
+
+	db.heap[key.HashKey()] = slices.Insert(db.heap[key.HashKey()], at,
+		entry{sortKey: key.SortKey(), item: item},
+	)
+}
+
+// remove item from partition
+func (db *Storage[T]) remove(key dynamo.Thing) {
+	at, has := db.lookup(key)
+	if !has {
+		return
+	}
+
+	seq := slices.Delete(db.heap[key.HashKey()], at, at+1)
+	if len(seq) == 0 {
+		delete(db.heap, key.HashKey())
+		return
+	}
+	db.heap[key.HashKey()] = seq
+}
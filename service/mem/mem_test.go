@@ -0,0 +1,232 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package mem_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/kvtest"
+	"github.com/fogfish/dynamo/v3/service/ddb"
+	"github.com/fogfish/dynamo/v3/service/mem"
+	"github.com/fogfish/faults"
+	"github.com/fogfish/it/v2"
+)
+
+type Person struct {
+	Prefix  curie.IRI `dynamodbav:"prefix,omitempty"`
+	Suffix  curie.IRI `dynamodbav:"suffix,omitempty"`
+	Name    string    `dynamodbav:"name,omitempty"`
+	Age     int       `dynamodbav:"age,omitempty"`
+	Address string    `dynamodbav:"address,omitempty"`
+	Tags    []string  `dynamodbav:"tags,omitempty,stringset"`
+}
+
+func (p Person) HashKey() curie.IRI { return p.Prefix }
+func (p Person) SortKey() curie.IRI { return p.Suffix }
+
+var (
+	name = ddb.ClauseFor[Person, string]("Name")
+	age  = ddb.ClauseFor[Person, int]("Age")
+
+	updateAge  = ddb.UpdateFor[Person, int]("Age")
+	updateTags = ddb.UpdateFor[Person, []string]("Tags")
+)
+
+func fixture(n int) *mem.Storage[Person] {
+	db := mem.New[Person]()
+	for i := 0; i < n; i++ {
+		db.Put(context.Background(),
+			Person{Prefix: "p", Suffix: curie.IRI("s:" + strconv.Itoa(i)), Name: "n" + strconv.Itoa(i), Age: i},
+		)
+	}
+	db.Put(context.Background(), Person{Prefix: "p", Suffix: "x:0"})
+	db.Put(context.Background(), Person{Prefix: "q", Suffix: "s:0"})
+	return db
+}
+
+func TestGetPutRemove(t *testing.T) {
+	db := mem.New[Person]()
+	key := Person{Prefix: "a", Suffix: "b"}
+	val := Person{Prefix: "a", Suffix: "b", Name: "x", Age: 10}
+
+	_, err := db.Get(context.Background(), key)
+	it.Then(t).ShouldNot(it.Nil(err)).Should(
+		it.True(faults.IsNotFound(err)),
+	)
+
+	err = db.Put(context.Background(), val)
+	it.Then(t).Should(it.Nil(err))
+
+	got, err := db.Get(context.Background(), key)
+	it.Then(t).Should(it.Nil(err), it.Equiv(got, val))
+
+	old, err := db.Remove(context.Background(), key)
+	it.Then(t).Should(it.Nil(err), it.Equiv(old, val))
+
+	_, err = db.Get(context.Background(), key)
+	it.Then(t).Should(it.True(faults.IsNotFound(err)))
+}
+
+func TestCondition(t *testing.T) {
+	db := mem.New[Person]()
+	val := Person{Prefix: "a", Suffix: "b", Name: "x", Age: 10}
+
+	err := db.Put(context.Background(), val, name.NotExists())
+	it.Then(t).Should(it.Nil(err))
+
+	err = db.Put(context.Background(), val, name.NotExists())
+	it.Then(t).Should(
+		it.True(faults.IsPreConditionFailed(err)),
+		it.True(faults.IsConflict(err)),
+	)
+
+	err = db.Put(context.Background(), val, name.Optimistic("x"))
+	it.Then(t).Should(it.Nil(err))
+
+	err = db.Put(context.Background(), val, ddb.OneOf(age.Gt(20), name.Eq("y")))
+	it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+
+	_, err = db.Remove(context.Background(), Person{Prefix: "a", Suffix: "c"}, name.Exists())
+	it.Then(t).Should(
+		it.True(faults.IsPreConditionFailed(err)),
+		it.True(faults.IsGone(err)),
+	)
+}
+
+func TestUpdate(t *testing.T) {
+	db := mem.New[Person]()
+	db.Put(context.Background(), Person{Prefix: "a", Suffix: "b", Name: "x", Age: 10})
+
+	val, err := db.Update(context.Background(), Person{Prefix: "a", Suffix: "b", Address: "y"})
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(val, Person{Prefix: "a", Suffix: "b", Name: "x", Age: 10, Address: "y"}),
+	)
+
+	_, err = db.Update(context.Background(), Person{Prefix: "a", Suffix: "b", Address: "z"}, name.Eq("z"))
+	it.Then(t).Should(it.True(faults.IsPreConditionFailed(err)))
+
+	val, err = db.UpdateWith(context.Background(),
+		ddb.Updater(Person{Prefix: "a", Suffix: "b"},
+			updateAge.Inc(5),
+			updateTags.Union([]string{"t1", "t2"}),
+		),
+		name.Eq("x"),
+	)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equal(val.Age, 15),
+		it.Seq(val.Tags).Equal("t1", "t2"),
+	)
+
+	val, err = db.UpdateWith(context.Background(),
+		ddb.Updater(Person{Prefix: "a", Suffix: "c", Name: "x"}, updateAge.SetNotExists(1)),
+	)
+	it.Then(t).Should(
+		it.Nil(err),
+		it.Equiv(val, Person{Prefix: "a", Suffix: "c", Age: 1}),
+	)
+}
+
+func TestMatch(t *testing.T) {
+	db := fixture(5)
+
+	t.Run("Prefix", func(t *testing.T) {
+		seq, cur, err := db.Match(context.Background(), Person{Prefix: "p", Suffix: "s:"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 5),
+			it.True(cur == nil),
+		)
+	})
+
+	t.Run("Partition", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "p"})
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 6),
+		)
+	})
+
+	t.Run("Paging", func(t *testing.T) {
+		key := Person{Prefix: "p", Suffix: "s:"}
+		seq, cur, err := db.Match(context.Background(), key, dynamo.Limit[Person](3))
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 3),
+			it.Equal(seq[2].Suffix, "s:2"),
+		)
+
+		seq, cur, err = db.Match(context.Background(), key, dynamo.Limit[Person](3), cur)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 2),
+			it.Equal(seq[0].Suffix, "s:3"),
+			it.True(cur == nil),
+		)
+	})
+
+	t.Run("Reverse", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "p", Suffix: "s:"},
+			dynamo.Reverse[Person](),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(seq[0].Suffix, "s:4"),
+		)
+	})
+
+	t.Run("Filter", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "p", Suffix: "s:"},
+			ddb.Filter(age.Ge(3)),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 2),
+		)
+	})
+
+	t.Run("KeyCondition", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), Person{Prefix: "p"},
+			ddb.SortKey[Person]().Between("s:1", "s:3"),
+		)
+		it.Then(t).Should(
+			it.Nil(err),
+			it.Equal(len(seq), 3),
+		)
+	})
+
+	t.Run("InvalidLimit", func(t *testing.T) {
+		for _, limit := range []int32{0, -1} {
+			_, _, err := db.Match(context.Background(), Person{Prefix: "p"}, dynamo.Limit[Person](limit))
+			it.Then(t).ShouldNot(it.Nil(err))
+		}
+	})
+
+	t.Run("MatchSeq", func(t *testing.T) {
+		n := 0
+		for _, err := range db.MatchSeq(context.Background(), Person{Prefix: "p", Suffix: "s:"},
+			dynamo.Limit[Person](2),
+		) {
+			it.Then(t).Should(it.Nil(err))
+			n++
+		}
+		it.Then(t).Should(it.Equal(n, 5))
+	})
+}
+
+func TestConformance(t *testing.T) {
+	kvtest.TestKeyVal(t, func() dynamo.KeyVal[kvtest.Person] {
+		return mem.New[kvtest.Person]()
+	})
+}
@@ -0,0 +1,32 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package mem
+
+import (
+	"context"
+
+	"github.com/fogfish/dynamo/v3/internal/kv"
+)
+
+// Get item from storage
+func (db *Storage[T]) Get(ctx context.Context, key T, opts ...interface{ GetterOpt(T) }) (T, error) {
+	if err := kv.CheckTTL(opts); err != nil {
+		return db.undefined, err
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	item := db.get(key)
+	if item == nil {
+		return db.undefined, errNotFound(nil, key)
+	}
+
+	return kv.Decode[T](item)
+}
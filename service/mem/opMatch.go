@@ -0,0 +1,65 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package mem
+
+import (
+	"context"
+	"iter"
+
+	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/ddbexpr"
+	"github.com/fogfish/dynamo/v3/internal/iterator"
+	"github.com/fogfish/dynamo/v3/internal/kv"
+)
+
+// MatchKey applies a pattern matching to elements in the storage
+func (db *Storage[T]) MatchKey(ctx context.Context, key dynamo.Thing, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	return db.match(key, opts)
+}
+
+// Match applies a pattern matching to elements in the storage
+func (db *Storage[T]) Match(ctx context.Context, key T, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	return db.match(key, opts)
+}
+
+// MatchSeq lazily iterates over all elements matching the pattern
+func (db *Storage[T]) MatchSeq(ctx context.Context, key T, opts ...interface{ MatcherOpt(T) }) iter.Seq2[T, error] {
+	return iterator.Seq(ctx,
+		func(ctx context.Context, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+			return db.Match(ctx, key, opts...)
+		},
+		opts,
+	)
+}
+
+func (db *Storage[T]) match(key dynamo.Thing, opts []interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+	q, err := kv.NewQuery[T](key, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	partition := db.heap[q.HashKey]
+	return q.Eval(func(yield func(curie.IRI, func() (ddbexpr.Item, error)) bool) {
+		for i := range partition {
+			at := i
+			if q.Reverse {
+				at = len(partition) - 1 - i
+			}
+
+			e := partition[at]
+			if !yield(e.sortKey, func() (ddbexpr.Item, error) { return e.item, nil }) {
+				return
+			}
+		}
+	})
+}
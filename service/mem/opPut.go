@@ -0,0 +1,36 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package mem
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3/internal/kv"
+)
+
+// Put writes entity
+func (db *Storage[T]) Put(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) error {
+	gen, err := kv.Encode(entity)
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	err = kv.CheckCondition(entity, db.get(entity),
+		map[string]string{}, map[string]types.AttributeValue{}, opts)
+	if err != nil {
+		return err
+	}
+
+	db.put(entity, gen)
+	return nil
+}
@@ -0,0 +1,36 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package mem
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/fogfish/dynamo/v3/internal/kv"
+)
+
+// Remove discards the entity from the storage, it returns removed entity
+func (db *Storage[T]) Remove(ctx context.Context, key T, opts ...interface{ WriterOpt(T) }) (T, error) {
+	if key.HashKey() == "" {
+		return db.undefined, errInvalidKey.New(nil)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	item := db.get(key)
+	err := kv.CheckCondition(key, item,
+		map[string]string{}, map[string]types.AttributeValue{}, opts)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	db.remove(key)
+	return kv.Decode[T](item)
+}
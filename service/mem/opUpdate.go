@@ -0,0 +1,59 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package mem
+
+import (
+	"context"
+
+	"github.com/fogfish/dynamo/v3/internal/kv"
+	"github.com/fogfish/dynamo/v3/internal/updateexpr"
+	"github.com/fogfish/dynamo/v3/service/ddb"
+)
+
+// Update applies a partial patch to entity and returns new values.
+// Defined attributes of entity overwrite existing ones, the item is
+// created if it does not exist.
+func (db *Storage[T]) Update(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) (T, error) {
+	gen, err := kv.Encode(entity)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	updated, err := kv.Update(entity, gen, db.get(entity), opts)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	db.put(entity, updated)
+	return kv.Decode[T](updated)
+}
+
+// UpdateWith applies update expression (see ddb.UpdateFor) to the entity.
+// The item is created from the key if it does not exist.
+func (db *Storage[T]) UpdateWith(ctx context.Context, expression ddb.UpdateItemExpression[T], opts ...interface{ WriterOpt(T) }) (T, error) {
+	update := updateexpr.Of(expression)
+	entity := update.Entity.(T)
+	if entity.HashKey() == "" {
+		return db.undefined, errInvalidKey.New(nil)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	updated, err := kv.UpdateWith(entity, update, db.get(entity), opts)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	db.put(entity, updated)
+	return kv.Decode[T](updated)
+}
@@ -0,0 +1,152 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package s3
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"mime"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Codec encodes and decodes body of S3 objects. The storage writes objects
+// using the codec configured by WithCodec, objects are read using the codec
+// defined by Content-Type and Content-Encoding of the object.
+type Codec interface {
+	ContentType() string
+	ContentEncoding() string
+	Encode(io.Writer, any) error
+	Decode(io.Reader, any) error
+}
+
+// Built-in codecs
+var (
+	// JSON codec (application/json), default one
+	JSON Codec = jsonCodec{}
+
+	// JSON codec with gzip compression (application/json, gzip)
+	GzipJSON = Gzip(JSON)
+
+	// CBOR codec (application/cbor), struct fields use `cbor` or `json` tags
+	CBOR Codec = cborCodec{}
+
+	// MessagePack codec (application/msgpack), struct fields use `json` tags
+	MessagePack Codec = msgpackCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string     { return "application/json" }
+func (jsonCodec) ContentEncoding() string { return "" }
+
+func (jsonCodec) Encode(w io.Writer, v any) error { return json.NewEncoder(w).Encode(v) }
+func (jsonCodec) Decode(r io.Reader, v any) error { return json.NewDecoder(r).Decode(v) }
+
+type cborCodec struct{}
+
+func (cborCodec) ContentType() string     { return "application/cbor" }
+func (cborCodec) ContentEncoding() string { return "" }
+
+func (cborCodec) Encode(w io.Writer, v any) error { return cbor.NewEncoder(w).Encode(v) }
+func (cborCodec) Decode(r io.Reader, v any) error { return cbor.NewDecoder(r).Decode(v) }
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string     { return "application/msgpack" }
+func (msgpackCodec) ContentEncoding() string { return "" }
+
+func (msgpackCodec) Encode(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+func (msgpackCodec) Decode(r io.Reader, v any) error {
+	dec := msgpack.NewDecoder(r)
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// Gzip compresses the body encoded by the codec
+func Gzip(codec Codec) Codec { return gzipCodec{Codec: codec} }
+
+type gzipCodec struct{ Codec }
+
+func (gzipCodec) ContentEncoding() string { return "gzip" }
+
+func (c gzipCodec) Encode(w io.Writer, v any) error {
+	gz := gzip.NewWriter(w)
+	if err := c.Codec.Encode(gz, v); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func (c gzipCodec) Decode(r io.Reader, v any) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	return c.Codec.Decode(gz, v)
+}
+
+// codecs known by content type
+var codecs = map[string]Codec{
+	JSON.ContentType():        JSON,
+	CBOR.ContentType():        CBOR,
+	MessagePack.ContentType(): MessagePack,
+}
+
+// codecOf object, the configured codec has priority over built-in ones.
+// Objects of unknown content type are decoded as JSON, it is the format
+// used by earlier versions of the library.
+func (db *Storage[T]) codecOf(val *s3.GetObjectOutput) (Codec, error) {
+	contentType, _, _ := mime.ParseMediaType(aws.ToString(val.ContentType))
+	contentEncoding := aws.ToString(val.ContentEncoding)
+
+	if contentType == db.bodyCodec.ContentType() && contentEncoding == db.bodyCodec.ContentEncoding() {
+		return db.bodyCodec, nil
+	}
+
+	codec, has := codecs[contentType]
+	if !has {
+		codec = JSON
+	}
+
+	switch contentEncoding {
+	case "", "identity":
+		return codec, nil
+	case "gzip":
+		return Gzip(codec), nil
+	default:
+		return nil, errUnsupportedOpt.New(nil, "Content-Encoding "+contentEncoding)
+	}
+}
+
+// decodes body of the object
+func (db *Storage[T]) decode(val *s3.GetObjectOutput) (T, error) {
+	codec, err := db.codecOf(val)
+	if err != nil {
+		return db.undefined, err
+	}
+
+	var entity T
+	if err := codec.Decode(val.Body, &entity); err != nil {
+		return db.undefined, errInvalidEntity.New(err)
+	}
+
+	return entity, nil
+}
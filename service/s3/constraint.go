@@ -0,0 +1,91 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package s3
+
+import (
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/fogfish/dynamo/v3"
+)
+
+// IfNotExists option for Put, the object is created only if it does not
+// exist (If-None-Match: *). The write fails with PreConditionFailed/Conflict
+// error otherwise.
+func IfNotExists[T dynamo.Thing]() interface{ WriterOpt(T) } {
+	return precondition[T]{ifNoneMatch: "*"}
+}
+
+// IfMatch option for Put, Remove and Update, the write succeeds only if
+// ETag of the object matches the given one. The write fails with
+// PreConditionFailed/Conflict error if the object is changed and with
+// PreConditionFailed/Gone error if the object is missing.
+func IfMatch[T dynamo.Thing](etag string) interface{ WriterOpt(T) } {
+	return precondition[T]{ifMatch: etag}
+}
+
+type precondition[T dynamo.Thing] struct {
+	ifMatch     string
+	ifNoneMatch string
+}
+
+func (precondition[T]) WriterOpt(T) {}
+
+func (c precondition[T]) Precondition() (string, string) { return c.ifMatch, c.ifNoneMatch }
+
+// conditional headers of write request
+type conditional struct {
+	ifMatch     string
+	ifNoneMatch string
+}
+
+// clause of the condition as it is reported by errors
+func (c conditional) String() string {
+	switch {
+	case c.ifMatch != "":
+		return "If-Match: " + c.ifMatch
+	case c.ifNoneMatch != "":
+		return "If-None-Match: " + c.ifNoneMatch
+	}
+	return ""
+}
+
+func conditionalOf[T any](opts []interface{ WriterOpt(T) }) conditional {
+	var c conditional
+	for _, opt := range opts {
+		if v, ok := opt.(interface{ Precondition() (string, string) }); ok {
+			ifMatch, ifNoneMatch := v.Precondition()
+			if ifMatch != "" {
+				c.ifMatch = ifMatch
+			}
+			if ifNoneMatch != "" {
+				c.ifNoneMatch = ifNoneMatch
+			}
+		}
+	}
+	return c
+}
+
+// the AWS SDK does not declare If-Match header for PutObject
+func withIfMatch(etag string) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, smithyhttp.SetHeaderValue("If-Match", etag))
+	}
+}
+
+// applies conditional headers to PutObject request
+func (c conditional) put(req *s3.PutObjectInput) []func(*s3.Options) {
+	if c.ifNoneMatch != "" {
+		req.IfNoneMatch = aws.String(c.ifNoneMatch)
+	}
+	if c.ifMatch != "" {
+		return []func(*s3.Options){withIfMatch(c.ifMatch)}
+	}
+	return nil
+}
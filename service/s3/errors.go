@@ -10,7 +10,6 @@ package s3
 
 import (
 	"errors"
-	"fmt"
 
 	"github.com/fogfish/dynamo/v3"
 	"github.com/fogfish/faults"
@@ -20,26 +19,52 @@ const (
 	errUndefinedBucket = faults.Type("undefined S3 bucket")
 	errServiceIO       = faults.Type("service i/o failed")
 	errInvalidEntity   = faults.Type("invalid entity")
+	errUnsupportedOpt  = faults.Safe1[string]("unsupported option %s")
 )
 
 // NotFound is an error to handle unknown elements
 func errNotFound(err error, thing dynamo.Thing) error {
-	return &notFound{Thing: thing, err: err}
+	return &dynamo.NotFoundError{Thing: thing, Err: err}
 }
 
-type notFound struct {
-	dynamo.Thing
-	err error
+// errService classifies errors of AWS S3
+//
+//	https://docs.aws.amazon.com/AmazonS3/latest/API/ErrorResponses.html
+func errService(err error) error {
+	var e interface{ ErrorCode() string }
+	if !errors.As(err, &e) {
+		return err
+	}
+
+	switch e.ErrorCode() {
+	case "SlowDown", "ServiceUnavailable", "RequestLimitExceeded":
+		return &dynamo.ServiceError{Kind: dynamo.ErrThrottled, Err: err}
+	case "EntityTooLarge":
+		return &dynamo.ServiceError{Kind: dynamo.ErrItemTooLarge, Err: err}
+	case "InvalidArgument", "InvalidRequest", "InvalidObjectName", "KeyTooLongError":
+		return &dynamo.ServiceError{Kind: dynamo.ErrValidation, Err: err}
+	}
+
+	return err
 }
 
-func (e *notFound) Error() string {
-	return fmt.Sprintf("Not Found (%s, %s): %v", e.HashKey(), e.SortKey(), e.err)
+// errPreConditionFailed reports failure of conditional request, the object
+// is either changed (conflict) or missing (gone).
+func errPreConditionFailed(err error, thing dynamo.Thing, cond conditional, gone bool) error {
+	return &dynamo.PreConditionFailedError{
+		Thing:   thing,
+		Failure: dynamo.Failure(!gone, gone),
+		Failed:  cond.String(),
+		Err:     err,
+	}
 }
 
-func (e *notFound) Unwrap() error { return e.err }
+// recover
+func recoverPreconditionFailed(err error) bool {
+	var e interface{ ErrorCode() string }
 
-func (e *notFound) NotFound() string {
-	return e.HashKey().Safe() + " " + e.SortKey().Safe()
+	ok := errors.As(err, &e)
+	return ok && (e.ErrorCode() == "PreconditionFailed" || e.ErrorCode() == "ConditionalRequestConflict")
 }
 
 // recover
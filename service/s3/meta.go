@@ -0,0 +1,126 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+package s3
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// Meta is metadata of S3 object, it is not a part of object's body.
+// Embed it into the type to write metadata with Put and to read it with
+// Get and Match. Any type implementing S3Meta() *Meta is supported.
+//
+//	type Note struct {
+//		s3.Meta
+//		ID   curie.IRI `json:"id"`
+//		Text string    `json:"text"`
+//	}
+type Meta struct {
+	// User-defined metadata (x-amz-meta-*)
+	Metadata map[string]string `json:"-" cbor:"-"`
+
+	// Tags of the object, Get reads them only if the service implements
+	// GetObjectTagging (e.g. s3.Client)
+	Tags map[string]string `json:"-" cbor:"-"`
+
+	CacheControl string             `json:"-" cbor:"-"`
+	StorageClass types.StorageClass `json:"-" cbor:"-"`
+
+	// ETag and VersionId of the object are set by Get and Match, Put ignores
+	// them. Use s3.IfMatch option for concurrency checks.
+	ETag      string `json:"-" cbor:"-"`
+	VersionId string `json:"-" cbor:"-"`
+}
+
+func (meta *Meta) S3Meta() *Meta { return meta }
+
+// S3Tagging declares AWS API used to read tags of objects, it is optional
+type S3Tagging interface {
+	GetObjectTagging(context.Context, *s3.GetObjectTaggingInput, ...func(*s3.Options)) (*s3.GetObjectTaggingOutput, error)
+}
+
+// metadata of the entity, if it is supported by the type
+func metaOf[T any](entity *T) *Meta {
+	switch v := any(entity).(type) {
+	case interface{ S3Meta() *Meta }:
+		return v.S3Meta()
+	}
+
+	// T is pointer type
+	switch v := any(*entity).(type) {
+	case interface{ S3Meta() *Meta }:
+		return v.S3Meta()
+	}
+
+	return nil
+}
+
+// writes metadata of the entity to PutObject request
+func putMeta[T any](req *s3.PutObjectInput, entity *T) {
+	meta := metaOf(entity)
+	if meta == nil {
+		return
+	}
+
+	req.Metadata = meta.Metadata
+	req.StorageClass = meta.StorageClass
+
+	if meta.CacheControl != "" {
+		req.CacheControl = aws.String(meta.CacheControl)
+	}
+
+	if len(meta.Tags) != 0 {
+		tags := url.Values{}
+		for k, v := range meta.Tags {
+			tags.Set(k, v)
+		}
+		req.Tagging = aws.String(tags.Encode())
+	}
+}
+
+// reads metadata of the object to the entity
+func (db *Storage[T]) getMeta(ctx context.Context, key *string, val *s3.GetObjectOutput, entity *T) error {
+	meta := metaOf(entity)
+	if meta == nil {
+		return nil
+	}
+
+	meta.Metadata = val.Metadata
+	meta.CacheControl = aws.ToString(val.CacheControl)
+	meta.StorageClass = val.StorageClass
+	meta.ETag = aws.ToString(val.ETag)
+	meta.VersionId = aws.ToString(val.VersionId)
+	meta.Tags = nil
+
+	tagging, ok := db.service.(S3Tagging)
+	if !ok || aws.ToInt32(val.TagCount) == 0 {
+		return nil
+	}
+
+	tags, err := tagging.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket:    aws.String(db.bucket),
+		Key:       key,
+		VersionId: val.VersionId,
+	})
+	if err != nil {
+		return errServiceIO.New(errService(err))
+	}
+
+	meta.Tags = make(map[string]string, len(tags.TagSet))
+	for _, tag := range tags.TagSet {
+		meta.Tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return nil
+}
@@ -10,7 +10,6 @@ package s3
 
 import (
 	"context"
-	"encoding/json"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -18,6 +17,12 @@ import (
 
 // Get item from storage
 func (db *Storage[T]) Get(ctx context.Context, key T, opts ...interface{ GetterOpt(T) }) (T, error) {
+	entity, _, err := db.get(ctx, key, skipExpiredOf(opts))
+	return entity, err
+}
+
+// get fetches the object and its ETag
+func (db *Storage[T]) get(ctx context.Context, key T, skipExpired bool) (T, string, error) {
 	req := &s3.GetObjectInput{
 		Bucket: aws.String(db.bucket),
 		Key:    aws.String(db.codec.EncodeKey(key)),
@@ -27,17 +32,25 @@ func (db *Storage[T]) Get(ctx context.Context, key T, opts ...interface{ GetterO
 	if err != nil {
 		switch {
 		case recoverNoSuchKey(err):
-			return db.undefined, errNotFound(err, key)
+			return db.undefined, "", errNotFound(err, key)
 		default:
-			return db.undefined, errServiceIO.New(err)
+			return db.undefined, "", errServiceIO.New(errService(err))
 		}
 	}
+	defer val.Body.Close()
+
+	if skipExpired && isExpired(val) {
+		return db.undefined, "", errNotFound(nil, key)
+	}
 
-	var entity T
-	err = json.NewDecoder(val.Body).Decode(&entity)
+	entity, err := db.decode(val)
 	if err != nil {
-		return db.undefined, errInvalidEntity.New(err)
+		return db.undefined, "", err
+	}
+
+	if err := db.getMeta(ctx, req.Key, val, &entity); err != nil {
+		return db.undefined, "", err
 	}
 
-	return entity, nil
+	return entity, aws.ToString(val.ETag), nil
 }
@@ -10,54 +10,203 @@ package s3
 
 import (
 	"context"
-	"encoding/json"
+	"iter"
+	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/fogfish/curie/v2"
 	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/iterator"
 )
 
 func (db *Storage[T]) MatchKey(ctx context.Context, key dynamo.Thing, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
-	req := db.reqListObjects(key, opts)
-	return db.match(ctx, req)
+	if keysOnlyOf(opts) {
+		return nil, nil, errUnsupportedOpt.New(nil, "KeysOnly, use MatchKeys")
+	}
+
+	req, err := db.reqListObjects(key, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.match(ctx, req, skipExpiredOf(opts))
+}
+
+// Object is metadata of S3 object returned by MatchKeys. The object key
+// is exposed as the hash key, the object is usable as the cursor of Match.
+type Object struct {
+	Key          string
+	Size         int64
+	ETag         string
+	LastModified time.Time
+}
+
+func (obj Object) HashKey() curie.IRI { return curie.IRI(obj.Key) }
+func (obj Object) SortKey() curie.IRI { return "" }
+
+// MatchKeys lists objects matching the pattern, it returns metadata of
+// objects without fetching them.
+func (db *Storage[T]) MatchKeys(ctx context.Context, key dynamo.Thing, opts ...interface{ MatcherOpt(T) }) ([]Object, interface{ MatcherOpt(T) }, error) {
+	req, err := db.reqListObjects(key, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	val, err := db.service.ListObjectsV2(ctx, req)
+	if err != nil {
+		return nil, nil, errServiceIO.New(errService(err))
+	}
+
+	seq := make([]Object, aws.ToInt32(val.KeyCount))
+	for i := range seq {
+		obj := val.Contents[i]
+		seq[i] = Object{
+			Key:          aws.ToString(obj.Key),
+			Size:         aws.ToInt64(obj.Size),
+			ETag:         aws.ToString(obj.ETag),
+			LastModified: aws.ToTime(obj.LastModified),
+		}
+	}
+
+	return seq, lastKeyToCursor[T](val), nil
+}
+
+// MatchSeq lazily iterates over all objects matching the pattern, it
+// follows continuation of listing until the prefix or MaxItems is exhausted.
+func (db *Storage[T]) MatchSeq(ctx context.Context, key T, opts ...interface{ MatcherOpt(T) }) iter.Seq2[T, error] {
+	return iterator.Seq(ctx,
+		func(ctx context.Context, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
+			return db.Match(ctx, key, opts...)
+		},
+		opts,
+	)
 }
 
 func (db *Storage[T]) Match(ctx context.Context, key T, opts ...interface{ MatcherOpt(T) }) ([]T, interface{ MatcherOpt(T) }, error) {
-	req := db.reqListObjects(key, opts)
-	return db.match(ctx, req)
+	// S3 keys are not decodable to T, objects have to be fetched
+	if keysOnlyOf(opts) {
+		return nil, nil, errUnsupportedOpt.New(nil, "KeysOnly, use MatchKeys")
+	}
+
+	req, err := db.reqListObjects(key, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.match(ctx, req, skipExpiredOf(opts))
 }
 
-func (db *Storage[T]) match(ctx context.Context, req *s3.ListObjectsV2Input) ([]T, interface{ MatcherOpt(T) }, error) {
-	val, err := db.service.ListObjectsV2(context.Background(), req)
+func (db *Storage[T]) match(ctx context.Context, req *s3.ListObjectsV2Input, skipExpired bool) ([]T, interface{ MatcherOpt(T) }, error) {
+	val, err := db.service.ListObjectsV2(ctx, req)
+	if err != nil {
+		return nil, nil, errServiceIO.New(errService(err))
+	}
+
+	seq, err := db.fetchAll(ctx, val.Contents[:aws.ToInt32(val.KeyCount)], skipExpired)
 	if err != nil {
-		return nil, nil, errServiceIO.New(err)
+		return nil, nil, err
 	}
 
-	seq := make([]T, aws.ToInt32(val.KeyCount))
-	for i := 0; i < int(aws.ToInt32(val.KeyCount)); i++ {
-		req := &s3.GetObjectInput{
-			Bucket: aws.String(db.bucket),
-			Key:    val.Contents[i].Key,
+	return seq, lastKeyToCursor[T](val), nil
+}
+
+// fetches objects with bounded concurrency, the order of objects is preserved.
+// The first failure cancels other fetches, cancellation of the caller's
+// context fails the fetch even if some objects are fetched.
+func (db *Storage[T]) fetchAll(parent context.Context, objects []types.Object, skipExpired bool) ([]T, error) {
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+
+	var (
+		once    sync.Once
+		failure error
+	)
+
+	items := make([]T, len(objects))
+	found := make([]bool, len(objects))
+
+	db.parallel(len(objects), func(i int) {
+		if ctx.Err() != nil {
+			return
 		}
-		val, err := db.service.GetObject(ctx, req)
+
+		var err error
+		items[i], found[i], err = db.fetch(ctx, objects[i].Key, skipExpired)
 		if err != nil {
-			return nil, nil, errServiceIO.New(err)
+			once.Do(func() {
+				failure = err
+				cancel()
+			})
 		}
+	})
 
-		var head T
-		err = json.NewDecoder(val.Body).Decode(&head)
-		if err != nil {
-			return nil, nil, errInvalidEntity.New(err)
+	if failure != nil {
+		return nil, failure
+	}
+
+	// workers skip objects once the caller cancels the context
+	if err := parent.Err(); err != nil {
+		return nil, err
+	}
+
+	seq := make([]T, 0, len(objects))
+	for i := range objects {
+		if found[i] {
+			seq = append(seq, items[i])
 		}
+	}
+
+	return seq, nil
+}
+
+// fetches object, it returns false if object is expired
+func (db *Storage[T]) fetch(ctx context.Context, key *string, skipExpired bool) (T, bool, error) {
+	req := &s3.GetObjectInput{
+		Bucket: aws.String(db.bucket),
+		Key:    key,
+	}
+	val, err := db.service.GetObject(ctx, req)
+	if err != nil {
+		return db.undefined, false, errServiceIO.New(errService(err))
+	}
+	defer val.Body.Close()
 
-		seq[i] = head
+	if skipExpired && isExpired(val) {
+		return db.undefined, false, nil
 	}
 
-	return seq, lastKeyToCursor[T](val), nil
+	head, err := db.decode(val)
+	if err != nil {
+		return db.undefined, false, err
+	}
+
+	if err := db.getMeta(ctx, key, val, &head); err != nil {
+		return db.undefined, false, err
+	}
+
+	return head, true, nil
 }
 
-func (db *Storage[T]) reqListObjects(key dynamo.Thing, opts []interface{ MatcherOpt(T) }) *s3.ListObjectsV2Input {
+// run the function over objects with bounded concurrency
+func (db *Storage[T]) parallel(n int, f func(int)) {
+	concurrency := max(db.concurrency, 1)
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func (db *Storage[T]) reqListObjects(key dynamo.Thing, opts []interface{ MatcherOpt(T) }) (*s3.ListObjectsV2Input, error) {
 	var (
 		limit  int32   = 1000
 		cursor *string = nil
@@ -66,6 +215,11 @@ func (db *Storage[T]) reqListObjects(key dynamo.Thing, opts []interface{ Matcher
 		switch v := opt.(type) {
 		case interface{ Limit() int32 }:
 			limit = v.Limit()
+		case interface{ Reverse() bool }:
+			// S3 lists objects in ascending order only
+			if v.Reverse() {
+				return nil, errUnsupportedOpt.New(nil, "Reverse")
+			}
 		case dynamo.Thing:
 			cursor = aws.String(db.codec.EncodeKey(v))
 		}
@@ -76,7 +230,16 @@ func (db *Storage[T]) reqListObjects(key dynamo.Thing, opts []interface{ Matcher
 		MaxKeys:    aws.Int32(limit),
 		Prefix:     aws.String(db.codec.EncodeKey(key)),
 		StartAfter: cursor,
+	}, nil
+}
+
+func keysOnlyOf[T any](opts []interface{ MatcherOpt(T) }) bool {
+	for _, opt := range opts {
+		if v, ok := opt.(interface{ KeysOnly() bool }); ok && v.KeysOnly() {
+			return true
+		}
 	}
+	return false
 }
 
 type cursor struct{ hashKey, sortKey string }
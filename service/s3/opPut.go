@@ -11,7 +11,6 @@ package s3
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -19,20 +18,44 @@ import (
 
 // Put writes entity
 func (db *Storage[T]) Put(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) error {
-	gen, err := json.Marshal(entity)
-	if err != nil {
+	return db.put(ctx, entity, conditionalOf(opts), opts)
+}
+
+func (db *Storage[T]) put(ctx context.Context, entity T, cond conditional, opts []interface{ WriterOpt(T) }) error {
+	var gen bytes.Buffer
+	if err := db.bodyCodec.Encode(&gen, entity); err != nil {
 		return errInvalidEntity.New(err)
 	}
 
 	req := &s3.PutObjectInput{
-		Bucket: aws.String(db.bucket),
-		Key:    aws.String(db.codec.EncodeKey(entity)),
-		Body:   bytes.NewReader(gen),
+		Bucket:      aws.String(db.bucket),
+		Key:         aws.String(db.codec.EncodeKey(entity)),
+		Body:        bytes.NewReader(gen.Bytes()),
+		ContentType: aws.String(db.bodyCodec.ContentType()),
+	}
+
+	if enc := db.bodyCodec.ContentEncoding(); enc != "" {
+		req.ContentEncoding = aws.String(enc)
+	}
+
+	putMeta(req, &entity)
+
+	if t, ok := expiresAtOf(opts); ok {
+		if err := putExpiresAt(req, t); err != nil {
+			return errInvalidEntity.New(err)
+		}
 	}
 
-	_, err = db.service.PutObject(ctx, req)
+	_, err := db.service.PutObject(ctx, req, cond.put(req)...)
 	if err != nil {
-		return errServiceIO.New(err)
+		switch {
+		case recoverPreconditionFailed(err):
+			return errPreConditionFailed(err, entity, cond, false)
+		case cond.ifMatch != "" && recoverNoSuchKey(err):
+			return errPreConditionFailed(err, entity, cond, true)
+		default:
+			return errServiceIO.New(errService(err))
+		}
 	}
 
 	return nil
@@ -10,26 +10,51 @@ package s3
 
 import (
 	"context"
+	"errors"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/fogfish/dynamo/v3"
 )
 
 // Remove discards the entity from the table
 func (db *Storage[T]) Remove(ctx context.Context, key T, opts ...interface{ WriterOpt(T) }) (T, error) {
-	obj, err := db.Get(ctx, key)
+	cond := conditionalOf(opts)
+	if cond.ifNoneMatch != "" {
+		return db.undefined, errUnsupportedOpt.New(nil, "IfNotExists")
+	}
+
+	obj, etag, err := db.get(ctx, key, false)
 	if err != nil {
+		if cond.ifMatch != "" && errors.Is(err, dynamo.ErrNotFound) {
+			return db.undefined, errPreConditionFailed(err, key, cond, true)
+		}
 		return db.undefined, err
 	}
 
+	if cond.ifMatch != "" && cond.ifMatch != etag {
+		return db.undefined, errPreConditionFailed(nil, key, cond, false)
+	}
+
 	req := &s3.DeleteObjectInput{
 		Bucket: aws.String(db.bucket),
 		Key:    aws.String(db.codec.EncodeKey(key)),
 	}
 
+	if cond.ifMatch != "" {
+		req.IfMatch = aws.String(cond.ifMatch)
+	}
+
 	_, err = db.service.DeleteObject(ctx, req)
 	if err != nil {
-		return db.undefined, errServiceIO.New(err)
+		switch {
+		case recoverPreconditionFailed(err):
+			return db.undefined, errPreConditionFailed(err, key, cond, false)
+		case cond.ifMatch != "" && recoverNoSuchKey(err):
+			return db.undefined, errPreConditionFailed(err, key, cond, true)
+		default:
+			return db.undefined, errServiceIO.New(errService(err))
+		}
 	}
 
 	return obj, nil
@@ -10,44 +10,63 @@ package s3
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/fogfish/dynamo/v3"
 )
 
-// Update applies a partial patch to entity and returns new values
+// Update applies a partial patch to entity and returns new values.
+// The object is written only if it is not changed since it was read,
+// the read-merge-write is retried otherwise (see WithUpdateRetries).
+// Use IfMatch option to update the object of known ETag, it is not retried.
 func (db *Storage[T]) Update(ctx context.Context, entity T, opts ...interface{ WriterOpt(T) }) (T, error) {
-	req := &s3.GetObjectInput{
-		Bucket: aws.String(db.bucket),
-		Key:    aws.String(db.codec.EncodeKey(entity)),
+	cond := conditionalOf(opts)
+	if cond.ifNoneMatch != "" {
+		return db.undefined, errUnsupportedOpt.New(nil, "IfNotExists, use Put")
 	}
 
-	val, err := db.service.GetObject(ctx, req)
-	if err != nil {
-		var nsk *types.NoSuchKey
-		if errors.As(err, &nsk) {
-			err := db.Put(ctx, entity)
-			if err != nil {
-				return db.undefined, err
-			}
-			return entity, nil
+	var err error
+	for i := 0; i <= db.updateRetries; i++ {
+		var updated T
+		updated, err = db.update(ctx, entity, cond, opts)
+		if err == nil {
+			return updated, nil
 		}
 
-		return db.undefined, errServiceIO.New(err)
+		if cond.ifMatch != "" || !errors.Is(err, dynamo.ErrPreConditionFailed) {
+			return db.undefined, err
+		}
 	}
 
-	var existing T
-	err = json.NewDecoder(val.Body).Decode(&existing)
+	return db.undefined, err
+}
+
+func (db *Storage[T]) update(ctx context.Context, entity T, cond conditional, opts []interface{ WriterOpt(T) }) (T, error) {
+	existing, etag, err := db.get(ctx, entity, false)
 	if err != nil {
-		return db.undefined, errInvalidEntity.New(err)
+		if !errors.Is(err, dynamo.ErrNotFound) {
+			return db.undefined, err
+		}
+
+		if cond.ifMatch != "" {
+			return db.undefined, errPreConditionFailed(err, entity, cond, true)
+		}
+
+		// the object is created only if no one has done it concurrently
+		err := db.put(ctx, entity, conditional{ifNoneMatch: "*"}, opts)
+		if err != nil {
+			return db.undefined, err
+		}
+		return entity, nil
+	}
+
+	if cond.ifMatch != "" && cond.ifMatch != etag {
+		return db.undefined, errPreConditionFailed(nil, entity, cond, false)
 	}
 
 	updated := db.schema.Merge(entity, existing)
 
-	err = db.Put(ctx, updated)
+	err = db.put(ctx, updated, conditional{ifMatch: etag}, opts)
 	if err != nil {
 		return db.undefined, err
 	}
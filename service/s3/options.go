@@ -31,8 +31,11 @@ type Option = opts.Option[Options]
 
 // Config Options
 type Options struct {
-	prefixes curie.Prefixes
-	service  S3
+	prefixes      curie.Prefixes
+	service       S3
+	concurrency   int
+	updateRetries int
+	bodyCodec     Codec
 }
 
 func (c *Options) checkRequired() error {
@@ -51,6 +54,17 @@ var (
 	// Set DynamoDB client for the client
 	WithS3 = opts.ForType[Options, S3]()
 
+	// Number of concurrent requests used by Match to fetch objects, default one is 8
+	WithConcurrency = opts.ForName[Options, int]("concurrency")
+
+	// Number of times Update retries read-merge-write of the object changed
+	// concurrently, default one is 3
+	WithUpdateRetries = opts.ForName[Options, int]("updateRetries")
+
+	// Codec of objects written by the storage, default one is JSON.
+	// Objects are read using the codec defined by their Content-Type.
+	WithCodec = opts.ForName[Options, Codec]("bodyCodec")
+
 	// Configure client's DynamoDB to use provided the aws.Config
 	WithConfig = opts.FMap(optsFromConfig)
 
@@ -61,7 +75,10 @@ var (
 // NewConfig creates Config with default options
 func optsDefault() Options {
 	return Options{
-		prefixes: curie.Namespaces{},
+		prefixes:      curie.Namespaces{},
+		concurrency:   8,
+		updateRetries: 3,
+		bodyCodec:     JSON,
 	}
 }
 
@@ -10,6 +10,7 @@ package s3
 
 import (
 	"github.com/fogfish/dynamo/v3"
+	"github.com/fogfish/dynamo/v3/internal/schema"
 	"github.com/fogfish/opts"
 )
 
@@ -17,7 +18,7 @@ type Storage[T dynamo.Thing] struct {
 	Options
 	bucket    string
 	codec     *codec[T]
-	schema    *schema[T]
+	schema    *schema.Schema[T]
 	undefined T
 }
 
@@ -47,6 +48,6 @@ func New[T dynamo.Thing](bucket string, opt ...Option) (*Storage[T], error) {
 		Options: conf,
 		bucket:  bucket,
 		codec:   newCodec[T](conf.prefixes),
-		schema:  newSchema[T](),
+		schema:  schema.New[T](),
 	}, conf.checkRequired()
 }
@@ -10,12 +10,22 @@ package s3_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awss3 "github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	"github.com/fogfish/curie/v2"
+	"github.com/fogfish/dynamo/v3"
 	"github.com/fogfish/dynamo/v3/internal/dynamotest"
 	"github.com/fogfish/dynamo/v3/internal/s3test"
 	"github.com/fogfish/dynamo/v3/service/s3"
+	"github.com/fogfish/faults"
 	"github.com/fogfish/it"
 )
 
@@ -94,3 +104,523 @@ func TestSeqS3Update(t *testing.T) {
 		If(err).Should().Equal(nil).
 		If(val).Should().Equal(valS)
 }
+
+func TestS3MatchReverse(t *testing.T) {
+	key := dynamotest.Person{Prefix: "dead:beef"}
+	api := s3test.GetListObjects(&key, 1, &key, nil)
+
+	seq, _, err := api.Match(context.Background(), key, dynamo.Reverse[dynamotest.Person]())
+
+	it.Ok(t).
+		If(err).ShouldNot().Equal(nil).
+		If(len(seq)).Should().Equal(0)
+}
+
+func TestS3MatchCursorToken(t *testing.T) {
+	key := dynamotest.Person{Prefix: "dead:beef", Suffix: "1"}
+	api := s3test.GetListObjects(&key, 1, &key, &key)
+	codec, err := dynamo.NewCursorCodec[dynamotest.Person]()
+	it.Ok(t).IfNil(err)
+
+	_, cursor, err := api.Match(context.Background(), key)
+	it.Ok(t).IfNil(err).IfNotNil(cursor)
+
+	token, err := codec.Encode(cursor)
+	it.Ok(t).IfNil(err)
+
+	next, err := codec.Decode(token)
+	it.Ok(t).IfNil(err).
+		If(next.(dynamo.Thing).HashKey()).Should().Equal(cursor.(dynamo.Thing).HashKey())
+
+	seq, _, err := api.Match(context.Background(), key, next)
+	it.Ok(t).IfNil(err).
+		If(len(seq)).Should().Equal(1)
+}
+
+func TestS3MatchSeq(t *testing.T) {
+	key := dynamotest.Person{Prefix: "dead:beef", Suffix: "1"}
+	db := s3test.GetListObjects(&key, 3, &key, nil).(*s3.Storage[dynamotest.Person])
+
+	seq := []dynamotest.Person{}
+	for x, err := range db.MatchSeq(context.Background(), key, dynamo.MaxItems[dynamotest.Person](2)) {
+		it.Ok(t).IfNil(err)
+		seq = append(seq, x)
+	}
+
+	it.Ok(t).If(len(seq)).Should().Equal(2)
+}
+
+func TestS3TTL(t *testing.T) {
+	bucket := s3test.NewBucket()
+	db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(bucket)))
+
+	fresh := dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Name: "fresh"}
+	stale := dynamotest.Person{Prefix: "dead:beef", Suffix: "2", Name: "stale"}
+	expiresAt := time.Now().Add(-time.Minute)
+
+	err := db.Put(context.Background(), fresh, dynamo.TTL[dynamotest.Person](time.Hour))
+	it.Ok(t).IfNil(err).
+		If(bucket.Objects["dead:beef/1"].Tags[s3.TagTTLDays]).Should().Equal("1")
+
+	err = db.Put(context.Background(), fresh, dynamo.TTL[dynamotest.Person](72*time.Hour+time.Minute))
+	it.Ok(t).IfNil(err).
+		If(bucket.Objects["dead:beef/1"].Tags[s3.TagTTLDays]).Should().Equal("4")
+
+	err = db.Put(context.Background(), stale, dynamo.ExpiresAt[dynamotest.Person](expiresAt))
+	it.Ok(t).IfNil(err).
+		If(bucket.Objects["dead:beef/2"].Expires.Unix()).Should().Equal(expiresAt.Unix())
+
+	t.Run("Get", func(t *testing.T) {
+		_, err := db.Get(context.Background(), stale)
+		it.Ok(t).IfNil(err)
+
+		_, err = db.Get(context.Background(), stale, dynamo.SkipExpired[dynamotest.Person]())
+		it.Ok(t).IfTrue(faults.IsNotFound(err))
+
+		val, err := db.Get(context.Background(), fresh, dynamo.SkipExpired[dynamotest.Person]())
+		it.Ok(t).IfNil(err).
+			If(val).Should().Equal(fresh)
+	})
+
+	t.Run("Match", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), dynamotest.Person{Prefix: "dead:beef"})
+		it.Ok(t).IfNil(err).
+			If(len(seq)).Should().Equal(2)
+
+		seq, _, err = db.Match(context.Background(), dynamotest.Person{Prefix: "dead:beef"},
+			dynamo.SkipExpired[dynamotest.Person](),
+		)
+		it.Ok(t).IfNil(err).
+			If(seq).Should().Equal([]dynamotest.Person{fresh})
+	})
+}
+
+func TestS3Errors(t *testing.T) {
+	key := dynamotest.Person{Prefix: "dead:beef", Suffix: "1"}
+
+	t.Run("NotFound", func(t *testing.T) {
+		db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(s3test.NewBucket())))
+
+		_, err := db.Get(context.Background(), key)
+		it.Ok(t).IfTrue(errors.Is(err, dynamo.ErrNotFound))
+	})
+
+	for code, kind := range map[string]error{
+		"SlowDown":        dynamo.ErrThrottled,
+		"EntityTooLarge":  dynamo.ErrItemTooLarge,
+		"InvalidArgument": dynamo.ErrValidation,
+	} {
+		t.Run(code, func(t *testing.T) {
+			fail := &smithy.GenericAPIError{Code: code, Message: "failed"}
+			db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(&failingS3{err: fail})))
+
+			var e *smithy.GenericAPIError
+			err := db.Put(context.Background(), key)
+			it.Ok(t).
+				IfTrue(errors.Is(err, kind)).
+				IfTrue(errors.As(err, &e))
+		})
+	}
+}
+
+// failingS3 fails every write with the error
+type failingS3 struct {
+	s3.S3
+	err error
+}
+
+func (mock *failingS3) PutObject(context.Context, *awss3.PutObjectInput, ...func(*awss3.Options)) (*awss3.PutObjectOutput, error) {
+	return nil, mock.err
+}
+
+func TestS3MatchConcurrency(t *testing.T) {
+	seq := make([]dynamotest.Person, 20)
+	for i := range seq {
+		seq[i] = dynamotest.Person{Prefix: "dead:beef", Suffix: curie.IRI(fmt.Sprintf("%02d", i)), Name: "person"}
+	}
+
+	setup := func(mock *slowS3) *s3.Storage[dynamotest.Person] {
+		db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(mock), s3.WithConcurrency(4)))
+		for _, x := range seq {
+			if err := db.Put(context.Background(), x); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return db
+	}
+
+	t.Run("Order", func(t *testing.T) {
+		mock := &slowS3{Bucket: s3test.NewBucket()}
+		db := setup(mock)
+
+		ctx := context.WithValue(context.Background(), ctxKey{}, "caller")
+		val, _, err := db.Match(ctx, dynamotest.Person{Prefix: "dead:beef"})
+		it.Ok(t).IfNil(err).
+			If(val).Should().Equal(seq).
+			If(mock.ctxValue).Should().Equal("caller").
+			If(mock.peak.Load() <= 4).Should().Equal(true).
+			If(mock.peak.Load() > 1).Should().Equal(true)
+	})
+
+	t.Run("Failure", func(t *testing.T) {
+		mock := &slowS3{Bucket: s3test.NewBucket(), fail: "dead:beef/05"}
+		db := setup(mock)
+
+		_, _, err := db.Match(context.Background(), dynamotest.Person{Prefix: "dead:beef"})
+		it.Ok(t).IfNotNil(err).
+			If(mock.calls.Load() < int32(len(seq))).Should().Equal(true)
+	})
+
+	t.Run("Cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		mock := &slowS3{Bucket: s3test.NewBucket()}
+		db := setup(mock)
+		mock.cancel, mock.cancelAt = cancel, 2
+
+		db = s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(mock), s3.WithConcurrency(1)))
+		val, _, err := db.Match(ctx, dynamotest.Person{Prefix: "dead:beef"})
+		it.Ok(t).
+			IfTrue(errors.Is(err, context.Canceled)).
+			If(len(val)).Should().Equal(0)
+	})
+}
+
+type ctxKey struct{}
+
+// slowS3 delays GetObject to observe concurrency of requests
+type slowS3 struct {
+	*s3test.Bucket
+	fail     string
+	ctxValue any
+	cancel   func()
+	cancelAt int32
+	calls    atomic.Int32
+	active   atomic.Int32
+	peak     atomic.Int32
+}
+
+func (mock *slowS3) ListObjectsV2(ctx context.Context, input *awss3.ListObjectsV2Input, opts ...func(*awss3.Options)) (*awss3.ListObjectsV2Output, error) {
+	mock.ctxValue = ctx.Value(ctxKey{})
+	return mock.Bucket.ListObjectsV2(ctx, input, opts...)
+}
+
+func (mock *slowS3) GetObject(ctx context.Context, input *awss3.GetObjectInput, opts ...func(*awss3.Options)) (*awss3.GetObjectOutput, error) {
+	if call := mock.calls.Add(1); mock.cancel != nil && call == mock.cancelAt {
+		// the object is fetched but the caller is gone
+		defer mock.cancel()
+	}
+	n := mock.active.Add(1)
+	defer mock.active.Add(-1)
+	for {
+		peak := mock.peak.Load()
+		if n <= peak || mock.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	if aws.ToString(input.Key) == mock.fail {
+		return nil, errors.New("failed")
+	}
+
+	select {
+	case <-time.After(5 * time.Millisecond):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	return mock.Bucket.GetObject(ctx, input, opts...)
+}
+
+func TestS3MatchKeys(t *testing.T) {
+	bucket := s3test.NewBucket()
+	db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(bucket)))
+
+	seq := []dynamotest.Person{
+		{Prefix: "dead:beef", Suffix: "1", Name: "a"},
+		{Prefix: "dead:beef", Suffix: "2", Name: "b"},
+		{Prefix: "dead:beef", Suffix: "3", Name: "c"},
+	}
+	for _, x := range seq {
+		it.Ok(t).IfNil(db.Put(context.Background(), x))
+	}
+
+	t.Run("Keys", func(t *testing.T) {
+		keys, _, err := db.MatchKeys(context.Background(), dynamotest.Person{Prefix: "dead:beef"})
+		it.Ok(t).IfNil(err).
+			If(len(keys)).Should().Equal(3).
+			If(keys[0].Key).Should().Equal("dead:beef/1").
+			If(keys[0].Size).Should().Equal(int64(len(bucket.Objects["dead:beef/1"].Body))).
+			If(keys[0].ETag).Should().Equal(bucket.Objects["dead:beef/1"].ETag).
+			If(keys[0].LastModified.IsZero()).Should().Equal(false)
+	})
+
+	t.Run("Paging", func(t *testing.T) {
+		keys, cur, err := db.MatchKeys(context.Background(), dynamotest.Person{Prefix: "dead:beef"},
+			dynamo.Limit[dynamotest.Person](2),
+		)
+		it.Ok(t).IfNil(err).
+			If(len(keys)).Should().Equal(2).
+			IfNotNil(cur)
+
+		keys, cur, err = db.MatchKeys(context.Background(), dynamotest.Person{Prefix: "dead:beef"},
+			dynamo.Limit[dynamotest.Person](2), cur,
+		)
+		it.Ok(t).IfNil(err).
+			If(len(keys)).Should().Equal(1).
+			If(keys[0].Key).Should().Equal("dead:beef/3").
+			IfNil(cur)
+	})
+
+	t.Run("KeysOnly", func(t *testing.T) {
+		_, _, err := db.Match(context.Background(), dynamotest.Person{Prefix: "dead:beef"},
+			dynamo.KeysOnly[dynamotest.Person](),
+		)
+		it.Ok(t).IfNotNil(err)
+	})
+}
+
+func TestS3Codecs(t *testing.T) {
+	val := dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Name: "Verner", Age: 64}
+
+	for name, codec := range map[string]s3.Codec{
+		"JSON":        s3.JSON,
+		"GzipJSON":    s3.GzipJSON,
+		"CBOR":        s3.CBOR,
+		"MessagePack": s3.MessagePack,
+	} {
+		t.Run(name, func(t *testing.T) {
+			bucket := s3test.NewBucket()
+			db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(bucket), s3.WithCodec(codec)))
+
+			err := db.Put(context.Background(), val)
+			it.Ok(t).IfNil(err).
+				If(aws.ToString(bucket.Objects["dead:beef/1"].ContentType)).Should().Equal(codec.ContentType()).
+				If(aws.ToString(bucket.Objects["dead:beef/1"].ContentEncoding)).Should().Equal(codec.ContentEncoding())
+
+			got, err := db.Get(context.Background(), val)
+			it.Ok(t).IfNil(err).If(got).Should().Equal(val)
+
+			upd, err := db.Update(context.Background(), dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Age: 65})
+			it.Ok(t).IfNil(err).If(upd.Name).Should().Equal("Verner").If(upd.Age).Should().Equal(65)
+
+			seq, _, err := db.Match(context.Background(), dynamotest.Person{Prefix: "dead:beef"})
+			it.Ok(t).IfNil(err).If(len(seq)).Should().Equal(1).If(seq[0]).Should().Equal(upd)
+
+			// objects are decoded using their content type
+			reader := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(bucket)))
+			got, err = reader.Get(context.Background(), val)
+			it.Ok(t).IfNil(err).If(got).Should().Equal(upd)
+		})
+	}
+
+	t.Run("Legacy", func(t *testing.T) {
+		bucket := s3test.NewBucket()
+		bucket.Objects["dead:beef/1"] = &s3test.Object{Body: []byte(`{"Name":"Verner"}`)}
+
+		db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(bucket), s3.WithCodec(s3.CBOR)))
+		got, err := db.Get(context.Background(), val)
+		it.Ok(t).IfNil(err).If(got.Name).Should().Equal("Verner")
+	})
+
+	t.Run("UnknownEncoding", func(t *testing.T) {
+		bucket := s3test.NewBucket()
+		bucket.Objects["dead:beef/1"] = &s3test.Object{
+			Body:            []byte(`{}`),
+			ContentType:     aws.String("application/json"),
+			ContentEncoding: aws.String("br"),
+		}
+
+		db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(bucket)))
+		_, err := db.Get(context.Background(), val)
+		it.Ok(t).IfNotNil(err)
+	})
+}
+
+func TestS3Conditional(t *testing.T) {
+	val := dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Name: "Verner", Age: 64}
+
+	setup := func() (*s3test.Bucket, *s3.Storage[dynamotest.Person]) {
+		bucket := s3test.NewBucket()
+		db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(bucket)))
+		it.Ok(t).IfNil(db.Put(context.Background(), val))
+		return bucket, db
+	}
+
+	t.Run("IfNotExists", func(t *testing.T) {
+		_, db := setup()
+
+		err := db.Put(context.Background(), val, s3.IfNotExists[dynamotest.Person]())
+		it.Ok(t).
+			IfTrue(errors.Is(err, dynamo.ErrPreConditionFailed)).
+			IfTrue(errors.Is(err, dynamo.ErrConflict)).
+			IfTrue(faults.IsPreConditionFailed(err))
+
+		err = db.Put(context.Background(), dynamotest.Person{Prefix: "dead:beef", Suffix: "2"}, s3.IfNotExists[dynamotest.Person]())
+		it.Ok(t).IfNil(err)
+	})
+
+	t.Run("PutIfMatch", func(t *testing.T) {
+		bucket, db := setup()
+		etag := bucket.Objects["dead:beef/1"].ETag
+
+		err := db.Put(context.Background(), dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Name: "Jim"}, s3.IfMatch[dynamotest.Person](etag))
+		it.Ok(t).IfNil(err)
+
+		err = db.Put(context.Background(), val, s3.IfMatch[dynamotest.Person](etag))
+		it.Ok(t).
+			IfTrue(errors.Is(err, dynamo.ErrConflict)).
+			IfTrue(!errors.Is(err, dynamo.ErrGone))
+
+		err = db.Put(context.Background(), dynamotest.Person{Prefix: "dead:beef", Suffix: "2"}, s3.IfMatch[dynamotest.Person](etag))
+		it.Ok(t).IfTrue(errors.Is(err, dynamo.ErrGone))
+	})
+
+	t.Run("RemoveIfMatch", func(t *testing.T) {
+		bucket, db := setup()
+		etag := bucket.Objects["dead:beef/1"].ETag
+
+		_, err := db.Remove(context.Background(), val, s3.IfMatch[dynamotest.Person]("\"0\""))
+		it.Ok(t).IfTrue(errors.Is(err, dynamo.ErrConflict))
+
+		obj, err := db.Remove(context.Background(), val, s3.IfMatch[dynamotest.Person](etag))
+		it.Ok(t).IfNil(err).If(obj).Should().Equal(val)
+
+		_, err = db.Remove(context.Background(), val, s3.IfMatch[dynamotest.Person](etag))
+		it.Ok(t).IfTrue(errors.Is(err, dynamo.ErrGone))
+	})
+
+	t.Run("UpdateIfMatch", func(t *testing.T) {
+		bucket, db := setup()
+		etag := bucket.Objects["dead:beef/1"].ETag
+
+		_, err := db.Update(context.Background(), dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Age: 65}, s3.IfMatch[dynamotest.Person]("\"0\""))
+		it.Ok(t).IfTrue(errors.Is(err, dynamo.ErrConflict))
+
+		upd, err := db.Update(context.Background(), dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Age: 65}, s3.IfMatch[dynamotest.Person](etag))
+		it.Ok(t).IfNil(err).If(upd.Age).Should().Equal(65)
+
+		_, err = db.Update(context.Background(), dynamotest.Person{Prefix: "dead:beef", Suffix: "2", Age: 65}, s3.IfMatch[dynamotest.Person](etag))
+		it.Ok(t).IfTrue(errors.Is(err, dynamo.ErrGone))
+	})
+
+	t.Run("UpdateRetries", func(t *testing.T) {
+		bucket, _ := setup()
+		racy := &racyS3{Bucket: bucket, race: 2}
+		db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(racy)))
+
+		upd, err := db.Update(context.Background(), dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Age: 65})
+		it.Ok(t).IfNil(err).
+			If(upd.Address).Should().Equal("race 1").
+			If(upd.Age).Should().Equal(65)
+	})
+
+	t.Run("UpdateRetriesExhausted", func(t *testing.T) {
+		bucket, _ := setup()
+		racy := &racyS3{Bucket: bucket, race: 2}
+		db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(racy), s3.WithUpdateRetries(1)))
+
+		_, err := db.Update(context.Background(), dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Age: 65})
+		it.Ok(t).IfTrue(errors.Is(err, dynamo.ErrConflict))
+	})
+}
+
+// racyS3 changes the object concurrently with first writes
+type racyS3 struct {
+	*s3test.Bucket
+	race int
+}
+
+func (mock *racyS3) PutObject(ctx context.Context, input *awss3.PutObjectInput, opts ...func(*awss3.Options)) (*awss3.PutObjectOutput, error) {
+	if mock.race > 0 {
+		obj := dynamotest.Person{Prefix: "dead:beef", Suffix: "1", Name: "Verner", Address: fmt.Sprintf("race %d", mock.race)}
+		mock.race--
+
+		db := s3.Must(s3.New[dynamotest.Person]("test", s3.WithS3(mock.Bucket)))
+		if err := db.Put(ctx, obj); err != nil {
+			return nil, err
+		}
+	}
+
+	return mock.Bucket.PutObject(ctx, input, opts...)
+}
+
+type note struct {
+	s3.Meta
+	ID   curie.IRI `json:"id"`
+	Text string    `json:"text,omitempty"`
+}
+
+func (n note) HashKey() curie.IRI { return n.ID }
+func (n note) SortKey() curie.IRI { return "" }
+
+func TestS3Meta(t *testing.T) {
+	val := note{
+		Meta: s3.Meta{
+			Metadata:     map[string]string{"author": "verner"},
+			Tags:         map[string]string{"class": "secret", "level": "a b"},
+			CacheControl: "max-age=60",
+			StorageClass: types.StorageClassStandardIa,
+		},
+		ID:   "note:1",
+		Text: "hello",
+	}
+
+	bucket := s3test.NewBucket()
+	db := s3.Must(s3.New[note]("test", s3.WithS3(bucket)))
+	it.Ok(t).IfNil(db.Put(context.Background(), val))
+
+	t.Run("Put", func(t *testing.T) {
+		obj := bucket.Objects["note:1"]
+		it.Ok(t).
+			If(obj.Metadata).Should().Equal(val.Metadata).
+			If(obj.Tags).Should().Equal(val.Tags).
+			If(aws.ToString(obj.CacheControl)).Should().Equal("max-age=60").
+			If(obj.StorageClass).Should().Equal(types.StorageClassStandardIa).
+			If(string(obj.Body)).Should().Equal("{\"id\":\"[note:1]\",\"text\":\"hello\"}\n")
+	})
+
+	t.Run("Get", func(t *testing.T) {
+		obj, err := db.Get(context.Background(), note{ID: "note:1"})
+		it.Ok(t).IfNil(err).
+			If(obj.Text).Should().Equal("hello").
+			If(obj.Metadata).Should().Equal(val.Metadata).
+			If(obj.Tags).Should().Equal(val.Tags).
+			If(obj.CacheControl).Should().Equal("max-age=60").
+			If(obj.StorageClass).Should().Equal(types.StorageClassStandardIa).
+			If(obj.ETag).Should().Equal(bucket.Objects["note:1"].ETag).
+			If(obj.VersionId).Should().Equal(bucket.Objects["note:1"].VersionId)
+	})
+
+	t.Run("Match", func(t *testing.T) {
+		seq, _, err := db.Match(context.Background(), note{ID: "note:"})
+		it.Ok(t).IfNil(err).
+			If(len(seq)).Should().Equal(1).
+			If(seq[0].Tags).Should().Equal(val.Tags).
+			If(seq[0].ETag).Should().Equal(bucket.Objects["note:1"].ETag)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		obj, err := db.Get(context.Background(), note{ID: "note:1"})
+		it.Ok(t).IfNil(err)
+
+		upd, err := db.Update(context.Background(), note{ID: "note:1", Text: "world"}, s3.IfMatch[note](obj.ETag))
+		it.Ok(t).IfNil(err).
+			If(upd.Text).Should().Equal("world").
+			If(bucket.Objects["note:1"].Tags).Should().Equal(val.Tags).
+			If(bucket.Objects["note:1"].Metadata).Should().Equal(val.Metadata)
+
+		_, err = db.Update(context.Background(), note{ID: "note:1", Text: "again"}, s3.IfMatch[note](obj.ETag))
+		it.Ok(t).IfTrue(errors.Is(err, dynamo.ErrConflict))
+	})
+
+	t.Run("Pointer", func(t *testing.T) {
+		db := s3.Must(s3.New[*note]("test", s3.WithS3(bucket)))
+
+		obj, err := db.Get(context.Background(), &note{ID: "note:1"})
+		it.Ok(t).IfNil(err).
+			If(obj.Tags).Should().Equal(val.Tags).
+			If(obj.VersionId).Should().Equal(bucket.Objects["note:1"].VersionId)
+	})
+}
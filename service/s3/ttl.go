@@ -0,0 +1,85 @@
+//
+// Copyright (C) 2022 Dmitry Kolesnikov
+//
+// This file may be modified and distributed under the terms
+// of the MIT license.  See the LICENSE file for details.
+// https://github.com/fogfish/dynamo
+//
+
+//
+// The file implements time-to-live of objects using Expires header and
+// the expiry tag. S3 does not remove objects by the header, it is used only
+// to hide expired ones. The tag is used by lifecycle rules to remove them.
+//
+
+package s3
+
+import (
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// TagTTLDays is the object tag written by dynamo.TTL and dynamo.ExpiresAt
+// options. The value is number of days until expiry (rounded up), so that
+// lifecycle rule filters objects by the tag and expires them:
+//
+//	Filter: {Tag: {Key: "ttl-days", Value: "7"}}, Expiration: {Days: 7}
+const TagTTLDays = "ttl-days"
+
+// expiry of the object defined by options (e.g. dynamo.TTL)
+func expiresAtOf[T any](opts []interface{ WriterOpt(T) }) (time.Time, bool) {
+	for _, opt := range opts {
+		if v, ok := opt.(interface{ ExpiresAt() time.Time }); ok {
+			return v.ExpiresAt(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sets expiry of the object: Expires header and the tag for lifecycle rules
+func putExpiresAt(req *s3.PutObjectInput, t time.Time) error {
+	tags, err := url.ParseQuery(aws.ToString(req.Tagging))
+	if err != nil {
+		return err
+	}
+	tags.Set(TagTTLDays, ttlDaysOf(t))
+
+	req.Expires = aws.Time(t)
+	req.Tagging = aws.String(tags.Encode())
+	return nil
+}
+
+// number of days until expiry, at least one day
+func ttlDaysOf(t time.Time) string {
+	days := int(math.Ceil(time.Until(t).Hours() / 24))
+	if days < 1 {
+		days = 1
+	}
+	return strconv.Itoa(days)
+}
+
+// checks if options requests to hide expired objects (dynamo.SkipExpired)
+func skipExpiredOf[O any](opts []O) bool {
+	for _, opt := range opts {
+		if v, ok := any(opt).(interface{ SkipExpired() bool }); ok && v.SkipExpired() {
+			return true
+		}
+	}
+	return false
+}
+
+// checks if the object is logically expired
+func isExpired(val *s3.GetObjectOutput) bool {
+	if val.ExpiresString == nil {
+		return false
+	}
+
+	t, err := http.ParseTime(*val.ExpiresString)
+	return err == nil && !t.After(time.Now())
+}
@@ -14,6 +14,7 @@ package dynamo
 
 import (
 	"context"
+	"time"
 
 	"github.com/fogfish/curie/v2"
 )
@@ -115,3 +116,89 @@ func Cursor[T Thing](c Thing) interface{ MatcherOpt(T) } { return cursor[T]{c} }
 type cursor[T Thing] struct{ Thing }
 
 func (cursor[T]) MatcherOpt(T) {}
+
+// Cursor returns the wrapped cursor, storages use it to recover own cursor type
+func (c cursor[T]) Cursor() Thing { return c.Thing }
+
+// MaxItems option for sequences (e.g. MatchSeq), it caps the total number
+// of items returned by the sequence. Use Limit to control the page size.
+func MaxItems[T Thing](n int) interface{ MatcherOpt(T) } { return maxItems[T](n) }
+
+type maxItems[T Thing] int
+
+func (maxItems[T]) MatcherOpt(T) {}
+
+func (n maxItems[T]) MaxItems() int { return int(n) }
+
+// Reverse option for Match, items are returned in descending order of sort key
+func Reverse[T Thing]() interface{ MatcherOpt(T) } { return reverse[T]{} }
+
+type reverse[T Thing] struct{}
+
+func (reverse[T]) MatcherOpt(T) {}
+
+func (reverse[T]) Reverse() bool { return true }
+
+// ConsistentRead option for Get and Match, it requests strongly consistent read
+func ConsistentRead[T Thing]() interface {
+	GetterOpt(T)
+	MatcherOpt(T)
+} {
+	return consistentRead[T]{}
+}
+
+type consistentRead[T Thing] struct{}
+
+func (consistentRead[T]) GetterOpt(T) {}
+
+func (consistentRead[T]) MatcherOpt(T) {}
+
+func (consistentRead[T]) ConsistentRead() bool { return true }
+
+// TTL option for Put and Update, the item expires after the duration.
+// DynamoDB removes expired items eventually. S3 sets Expires header and
+// the "ttl-days" tag, objects are removed by lifecycle rule filtering on
+// the tag. See SkipExpired to hide them. Other storages reject the option.
+func TTL[T Thing](d time.Duration) interface{ WriterOpt(T) } { return ttl[T](d) }
+
+type ttl[T Thing] time.Duration
+
+func (ttl[T]) WriterOpt(T) {}
+
+func (ttl ttl[T]) ExpiresAt() time.Time { return time.Now().Add(time.Duration(ttl)) }
+
+// ExpiresAt option for Put and Update, the item expires at the given time.
+func ExpiresAt[T Thing](t time.Time) interface{ WriterOpt(T) } { return expiresAt[T]{t} }
+
+type expiresAt[T Thing] struct{ t time.Time }
+
+func (expiresAt[T]) WriterOpt(T) {}
+
+func (e expiresAt[T]) ExpiresAt() time.Time { return e.t }
+
+// SkipExpired option for Get and Match, it hides items that are logically
+// expired (see TTL) but not yet removed by the storage.
+func SkipExpired[T Thing]() interface {
+	GetterOpt(T)
+	MatcherOpt(T)
+} {
+	return skipExpired[T]{}
+}
+
+type skipExpired[T Thing] struct{}
+
+func (skipExpired[T]) GetterOpt(T) {}
+
+func (skipExpired[T]) MatcherOpt(T) {}
+
+func (skipExpired[T]) SkipExpired() bool { return true }
+
+// KeysOnly option for Match, the storage returns items with key attributes
+// only, other attributes are not fetched.
+func KeysOnly[T Thing]() interface{ MatcherOpt(T) } { return keysOnly[T]{} }
+
+type keysOnly[T Thing] struct{}
+
+func (keysOnly[T]) MatcherOpt(T) {}
+
+func (keysOnly[T]) KeysOnly() bool { return true }